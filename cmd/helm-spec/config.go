@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+)
+
+const configFileName = ".helm-spec.yaml"
+
+// flags that cannot be set in the config file
+var configExcludedFlags = map[string]bool{"help": true, "version": true}
+
+// flags whose relative paths are resolved against the config file directory
+var configPathFlags = map[string]bool{"schema-dir": true}
+
+// project-wide settings from a `.helm-spec.yaml` file. Any CLI flag can be given
+// in camel case, i.e. `outputFormat: yaml`, flags on the command line take precedence
+type projectConfig struct {
+	// path of the config file, empty if none was found
+	path string
+	// spec files or directories to run if none are given as arguments
+	specs []string
+	// path or name of the helm binary
+	helm string
+	// default values of CLI flags by camel case flag name
+	flags map[string]interface{}
+}
+
+// returns the `.helm-spec.yaml` in dir or the closest parent directory that has one
+func findConfigFile(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf(errFailedToGetAbsolutePathTemplate, dir)
+	}
+	for {
+		path := filepath.Join(dir, configFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// converts a camel case config key to a flag name, i.e. `outputFormat` to `output-format`
+func flagName(key string) string {
+	name := strings.Builder{}
+	for _, r := range key {
+		if unicode.IsUpper(r) {
+			name.WriteRune('-')
+			r = unicode.ToLower(r)
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
+
+// makes a path relative to the config file directory absolute
+func (c projectConfig) resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.path), path)
+}
+
+// discovers and parses the config file for dir, an empty config is returned if there is none
+func loadProjectConfig(dir string) (config projectConfig, err error) {
+	config.path, err = findConfigFile(dir)
+	if err != nil || config.path == "" {
+		return config, err
+	}
+	content, err := os.ReadFile(config.path)
+	if err != nil {
+		return config, err
+	}
+	raw := map[string]interface{}{}
+	if err = yaml.Unmarshal(content, &raw); err != nil {
+		return config, fmt.Errorf("invalid config file %v: %w", config.path, err)
+	}
+	config.flags = map[string]interface{}{}
+	for key, value := range raw {
+		switch key {
+		case "specs":
+			specs, ok := value.([]interface{})
+			if !ok {
+				return config, fmt.Errorf("`specs` in %v must be a list of paths", config.path)
+			}
+			for _, s := range specs {
+				config.specs = append(config.specs, config.resolvePath(fmt.Sprint(s)))
+			}
+		case "helm":
+			helm, ok := value.(string)
+			if !ok {
+				return config, fmt.Errorf("`helm` in %v must be a path", config.path)
+			}
+			// plain command names are looked up on the PATH
+			config.helm = helm
+			if filepath.Base(helm) != helm {
+				config.helm = config.resolvePath(helm)
+			}
+		default:
+			config.flags[key] = value
+		}
+	}
+	return config, nil
+}
+
+// sets all flags from the config file that were not given on the command line.
+// Settings are validated against all flags of the app, but only the given flags are set
+func (c projectConfig) apply(cCtx *cli.Context, flags []cli.Flag) error {
+	known := map[string]cli.Flag{}
+	for _, f := range cCtx.App.Flags {
+		known[f.Names()[0]] = f
+	}
+	applicable := map[string]cli.Flag{}
+	for _, f := range flags {
+		applicable[f.Names()[0]] = f
+	}
+	keys := []string{}
+	for key := range c.flags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := c.flags[key]
+		name := flagName(key)
+		f, ok := known[name]
+		if !ok || configExcludedFlags[name] {
+			return fmt.Errorf("unknown setting `%v` in %v", key, c.path)
+		}
+		if _, ok := applicable[name]; !ok || value == nil || cCtx.IsSet(name) {
+			continue
+		}
+		values := []interface{}{value}
+		if list, isList := value.([]interface{}); isList {
+			if _, isSlice := f.(*cli.StringSliceFlag); !isSlice {
+				return fmt.Errorf("setting `%v` in %v must not be a list", key, c.path)
+			}
+			values = list
+		}
+		for _, v := range values {
+			s := fmt.Sprint(v)
+			if configPathFlags[name] {
+				s = c.resolvePath(s)
+			}
+			if err := cCtx.Set(name, s); err != nil {
+				return fmt.Errorf("invalid setting `%v` in %v: %w", key, c.path, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/bujarmurati/helm-spec/internal/testreport"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `outputFormat: yaml
+verbose: true
+jobs: 3
+tags: [a, b]
+timeout: 1m
+specs:
+- specs
+helm: ./bin/helm
+`
+
+// creates a project with a config file and a spec, returns the project directory
+func writeTestProject(t *testing.T, config string) string {
+	t.Helper()
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "specs", "nested"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, configFileName), []byte(config), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "specs", "foo_spec.yaml"), []byte("title: foo\n"), 0o644))
+	return dir
+}
+
+// runs the CLI with the config file discovered from dir
+func testRunWithConfig(t *testing.T, dir string, args []string) (settings testCLISettings, err error) {
+	t.Helper()
+	settings = newTestCLISettings()
+	settings.ConfigDir = dir
+	app, err := createApp(settings.cliSettings)
+	assert.NoError(t, err)
+	return settings, app.Run(args)
+}
+
+func TestFindConfigFileInParentDirectories(t *testing.T) {
+	dir := writeTestProject(t, testConfig)
+	path, err := findConfigFile(filepath.Join(dir, "specs", "nested"))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, configFileName), path)
+
+	path, err = findConfigFile(t.TempDir())
+	assert.NoError(t, err)
+	assert.Equal(t, "", path)
+}
+
+func TestFlagName(t *testing.T) {
+	assert.Equal(t, "output-format", flagName("outputFormat"))
+	assert.Equal(t, "skip-dependency-build", flagName("skipDependencyBuild"))
+	assert.Equal(t, "jobs", flagName("jobs"))
+}
+
+func TestConfigFileSuppliesDefaults(t *testing.T) {
+	dir := writeTestProject(t, testConfig)
+	settings, err := testRunWithConfig(t, filepath.Join(dir, "specs", "nested"), []string{"helm-spec"})
+	assert.NoError(t, err)
+	runner := settings.TestRunner.(*mockTestRunner)
+	assert.Equal(t, []string{filepath.Join(dir, "specs", "foo_spec.yaml")}, runner.SpecFiles)
+	assert.Equal(t, 3, runner.Settings.Jobs)
+	assert.Equal(t, []string{"a", "b"}, runner.Settings.Filter.Tags)
+	assert.Equal(t, filepath.Join(dir, "bin", "helm"), runner.Settings.HelmBinary)
+	assert.Equal(t, helmspec.CLIRenderer{Binary: filepath.Join(dir, "bin", "helm")}, runner.Settings.Renderer)
+	reportSettings := settings.TestReporter.(*mockTestReporter).Settings
+	assert.Equal(t, testreport.OutputFormatYAML, reportSettings.OutputFormat)
+	assert.True(t, reportSettings.Verbose)
+}
+
+func TestCLIFlagsTakePrecedenceOverConfigFile(t *testing.T) {
+	dir := writeTestProject(t, testConfig)
+	settings, err := testRunWithConfig(t, dir, []string{"helm-spec", "-o", "pretty", "-j", "2", "--tags", "c", "./testdata/specs"})
+	assert.NoError(t, err)
+	runner := settings.TestRunner.(*mockTestRunner)
+	assert.Equal(t, []string{"testdata/specs/example_spec.yaml"}, runner.SpecFiles)
+	assert.Equal(t, 2, runner.Settings.Jobs)
+	assert.Equal(t, []string{"c"}, runner.Settings.Filter.Tags)
+	assert.Equal(t, testreport.OutputFormatPretty, settings.TestReporter.(*mockTestReporter).Settings.OutputFormat)
+}
+
+func TestHelmBinaryNameIsLookedUpOnPath(t *testing.T) {
+	dir := writeTestProject(t, "helm: helm3\n")
+	settings, err := testRunWithConfig(t, dir, []string{"helm-spec", "./testdata/specs"})
+	assert.NoError(t, err)
+	assert.Equal(t, "helm3", settings.TestRunner.(*mockTestRunner).Settings.HelmBinary)
+}
+
+func TestInvalidConfigFile(t *testing.T) {
+	cases := []struct {
+		config string
+		err    string
+	}{
+		{config: "outputFormatt: yaml\n", err: "unknown setting `outputFormatt`"},
+		{config: "version: true\n", err: "unknown setting `version`"},
+		{config: "jobs: [1, 2]\n", err: "setting `jobs`"},
+		{config: "jobs: many\n", err: "invalid setting `jobs`"},
+		{config: "specs: specs\n", err: "`specs`"},
+	}
+	for _, c := range cases {
+		dir := writeTestProject(t, c.config)
+		_, err := testRunWithConfig(t, dir, []string{"helm-spec", "./testdata/specs"})
+		assert.ErrorContains(t, err, c.err)
+	}
+}
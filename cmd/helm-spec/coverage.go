@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/bujarmurati/helm-spec/internal/testreport"
+	"github.com/urfave/cli/v2"
+)
+
+// reports which parts of the charts are exercised by the specs
+func coverageCommand(settings cliSettings) *cli.Command {
+	return &cli.Command{
+		Name:  "coverage",
+		Usage: "report which parts of the charts the specs exercise",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "values",
+				Usage:     "report which keys of the charts' values.yaml are overridden by the specs",
+				ArgsUsage: "<spec files or directories (default: \"./specs\")>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:    "output-format",
+						Aliases: []string{"o"},
+						Value:   testreport.OutputFormatPretty,
+						Usage:   "output format for the report, one of \"pretty\"|\"yaml\"",
+					},
+				}, renderFlags()...),
+				Action: func(cCtx *cli.Context) error {
+					config, err := loadConfig(cCtx, settings, cCtx.Command.Flags)
+					if err != nil {
+						return err
+					}
+					specFiles, err := specFilesFromArgs(cCtx, config)
+					if err != nil {
+						return err
+					}
+					runSettings, err := renderSettingsFromFlags(cCtx, config)
+					if err != nil {
+						return err
+					}
+					specs, err := helmspec.LoadSpecs(specFiles)
+					if err != nil {
+						return err
+					}
+					coverage, err := helmspec.ComputeValuesCoverage(cCtx.Context, specs, runSettings)
+					if err != nil {
+						return err
+					}
+					report, err := testreport.ValuesCoverageReport(coverage, testreport.TestReportSettings{
+						OutputFormat: cCtx.String("output-format"),
+						UseColor:     !isColorDisabled(cCtx),
+					})
+					if err != nil {
+						return err
+					}
+					fmt.Fprint(settings.Writer, report)
+					return nil
+				},
+			},
+		},
+	}
+}
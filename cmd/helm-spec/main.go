@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/bujarmurati/helm-spec/internal/helmspec"
 	"github.com/bujarmurati/helm-spec/internal/testreport"
@@ -15,9 +22,8 @@ import (
 
 const (
 	errFailedToGetAbsolutePathTemplate = "failed to get absolute path of: %v"
-	errNotADirectoryTemplate           = "%v is not a directory"
 	errNoSpecFilesFoundTemplate        = "no %v files found in %v"
-	specFileGlobPattern                = "*_spec.yaml"
+	defaultSpecFilePattern             = "*_spec.yaml"
 	defaultSpecDir                     = "./specs"
 )
 
@@ -30,6 +36,11 @@ type cliSettings struct {
 	ExitErrHandler cli.ExitErrHandlerFunc
 	TestRunner     helmspec.TestRunner
 	TestReporter   testreport.TestReporter
+	// how often watch mode checks for changed files
+	WatchInterval time.Duration
+	// directory to discover the `.helm-spec.yaml` config file from,
+	// defaults to the working directory
+	ConfigDir string
 }
 
 var defaultSettings = cliSettings{
@@ -39,29 +50,82 @@ var defaultSettings = cliSettings{
 	ExitErrHandler: nil,
 	TestRunner:     &helmspec.HelmTestRunner{},
 	TestReporter:   testreport.HelmTestReporter{},
+	WatchInterval:  500 * time.Millisecond,
 }
 
-// validates that the path is an existing directory containing spec files
-func validateSpecDirPath(value string) (err error) {
+// moves the cursor to the top left corner and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// returns the spec files for a spec path argument. Files are used as they are,
+// directories are searched for files matching the pattern, including all
+// subdirectories if recursive is set
+func findSpecFiles(value string, pattern string, recursive bool) (specFiles []string, err error) {
 	absPath, err := filepath.Abs(value)
 	if err != nil {
-		return fmt.Errorf(errFailedToGetAbsolutePathTemplate, value)
+		return nil, fmt.Errorf(errFailedToGetAbsolutePathTemplate, value)
 	}
 	info, err := os.Stat(absPath)
 	if err != nil {
-		return fmt.Errorf("spec directory `%v` does not seem to exist: %w", value, err)
+		return nil, fmt.Errorf("spec path `%v` does not seem to exist: %w", value, err)
 	}
 	if !info.IsDir() {
-		return fmt.Errorf(errNotADirectoryTemplate, absPath)
+		return []string{value}, nil
 	}
-	specFiles, err := filepath.Glob(filepath.Join(absPath, specFileGlobPattern))
-	if err != nil {
-		return err
+	if !recursive {
+		specFiles, err = filepath.Glob(filepath.Join(value, pattern))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = filepath.WalkDir(value, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				// skip hidden directories like `.git`
+				if path != value && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if matched, _ := filepath.Match(pattern, d.Name()); matched {
+				specFiles = append(specFiles, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 	if len(specFiles) == 0 {
-		return fmt.Errorf(errNoSpecFilesFoundTemplate, specFileGlobPattern, absPath)
+		return nil, fmt.Errorf(errNoSpecFilesFoundTemplate, pattern, absPath)
 	}
-	return nil
+	return specFiles, nil
+}
+
+// collects the spec files of all spec path arguments, skipping duplicates
+func collectSpecFiles(paths []string, pattern string, recursive bool) (specFiles []string, err error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid spec file pattern `%v`: %w", pattern, err)
+	}
+	seen := map[string]bool{}
+	for _, p := range paths {
+		files, err := findSpecFiles(p, pattern, recursive)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			absPath, err := filepath.Abs(f)
+			if err != nil {
+				return nil, fmt.Errorf(errFailedToGetAbsolutePathTemplate, f)
+			}
+			if !seen[absPath] {
+				seen[absPath] = true
+				specFiles = append(specFiles, f)
+			}
+		}
+	}
+	return specFiles, nil
 }
 
 func validateOutputFormat(o string) (err error) {
@@ -81,48 +145,184 @@ func isColorDisabled(cCtx *cli.Context) bool {
 	return cCtx.Bool("no-color") || isNoColorSet || isHelmSpecNoColorSet || isTerminalDumb
 }
 
+// flags for finding spec files, shared by all commands reading specs
+func specFileFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "pattern",
+			Value: defaultSpecFilePattern,
+			Usage: "file name pattern of spec files in spec directories",
+		},
+		&cli.BoolFlag{
+			Name:    "recursive",
+			Aliases: []string{"r"},
+			Value:   false,
+			Usage:   "search spec directories and all their subdirectories for spec files",
+		},
+	}
+}
+
+// flags for finding spec files and rendering charts, shared by all commands running specs
+func renderFlags() []cli.Flag {
+	return append(specFileFlags(),
+		&cli.BoolFlag{
+			Name:  "no-color",
+			Value: false,
+			Usage: "disable colorful output",
+		},
+		&cli.IntFlag{
+			Name:    "jobs",
+			Aliases: []string{"j"},
+			Value:   runtime.NumCPU(),
+			Usage:   "maximum number of test cases to run in parallel",
+		},
+		&cli.BoolFlag{
+			Name:  "skip-dependency-build",
+			Value: false,
+			Usage: "do not build chart dependencies, i.e. for charts with vendored dependencies",
+		},
+		&cli.StringFlag{
+			Name:  "renderer",
+			Value: helmspec.RendererCLI,
+			Usage: "how charts are rendered, one of \"cli\" (the helm binary on the PATH)|\"sdk\" (the bundled helm SDK)",
+		},
+	)
+}
+
+// discovers the project config file and applies it to the flags of the current command
+func loadConfig(cCtx *cli.Context, settings cliSettings, flags []cli.Flag) (config projectConfig, err error) {
+	configDir := settings.ConfigDir
+	if configDir == "" {
+		configDir = "."
+	}
+	if config, err = loadProjectConfig(configDir); err != nil {
+		return config, err
+	}
+	return config, config.apply(cCtx, flags)
+}
+
+// returns the spec files of the spec path arguments, falling back
+// to the spec roots of the config file and the default spec directory
+func specFilesFromArgs(cCtx *cli.Context, config projectConfig) ([]string, error) {
+	specPaths := cCtx.Args().Slice()
+	if len(specPaths) == 0 {
+		specPaths = config.specs
+	}
+	if len(specPaths) == 0 {
+		specPaths = []string{defaultSpecDir}
+	}
+	return collectSpecFiles(specPaths, cCtx.String("pattern"), cCtx.Bool("recursive"))
+}
+
+// returns the test run settings of the flags in renderFlags
+func renderSettingsFromFlags(cCtx *cli.Context, config projectConfig) (settings helmspec.TestRunSettings, err error) {
+	jobs := cCtx.Int("jobs")
+	if jobs < 1 {
+		return settings, fmt.Errorf("jobs must be a positive number, got %v", jobs)
+	}
+	renderer, err := helmspec.NewRenderer(cCtx.String("renderer"), config.helm)
+	if err != nil {
+		return settings, fmt.Errorf("renderer must be one of `%v`", helmspec.AllowedRenderers)
+	}
+	return helmspec.TestRunSettings{
+		Jobs:                jobs,
+		SkipDependencyBuild: cCtx.Bool("skip-dependency-build"),
+		Renderer:            renderer,
+		HelmBinary:          config.helm,
+	}, nil
+}
+
 func createApp(settings cliSettings) (app *cli.App, err error) {
 	app = &cli.App{
-		Name:            "helm-spec",
-		Usage:           "automated tests for helm charts",
-		ArgsUsage:       "<spec directory (default: \"./specs\")>",
+		Name:      "helm-spec",
+		Usage:     "automated tests for helm charts",
+		ArgsUsage: "<spec files or directories (default: \"./specs\")>",
+		// urfave/cli matches subcommands before arguments
+		Description:     "The subcommand names \"coverage\", \"validate\" and \"schema\" are reserved, pass spec directories with these names as a path, i.e. \"./coverage\".",
 		HideHelpCommand: true,
-		Flags: []cli.Flag{
+		Commands:        []*cli.Command{coverageCommand(settings), validateCommand(settings), schemaCommand(settings)},
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "output-format",
 				Aliases: []string{"o"},
 				Value:   "pretty",
-				Usage:   "output format for the report, one of \"pretty\"|\"yaml\"",
+				Usage:   "output format for the report, one of \"pretty\"|\"yaml\"|\"junit\"",
 			},
 			&cli.BoolFlag{
-				Name:  "no-color",
+				Name:  "verbose",
 				Value: false,
-				Usage: "disable colorful output",
+				Usage: "verbose output includes rendered manifests for failed test cases",
 			},
+		}, append(renderFlags(),
 			&cli.BoolFlag{
-				Name:  "verbose",
+				Name:  "update-snapshots",
 				Value: false,
-				Usage: "verbose output includes rendered manifests for failed test cases",
+				Usage: "overwrite stored snapshots with the rendered manifests",
+			},
+			&cli.BoolFlag{
+				Name:  "validate-schemas",
+				Value: false,
+				Usage: "validate rendered manifests against kubernetes json schemas. Without --schema-dir only field names and value types are checked, against the API types of kubernetes " + helmspec.TypeSchemaKubeVersion,
+			},
+			&cli.StringFlag{
+				Name:  "schema-kube-version",
+				Value: helmspec.TypeSchemaKubeVersion,
+				Usage: "kubernetes version of the schemas to validate against, requires --validate-schemas. Versions other than " + helmspec.TypeSchemaKubeVersion + " require --schema-dir",
+			},
+			&cli.StringFlag{
+				Name:  "schema-dir",
+				Usage: "local directory with kubernetes json schemas, i.e. a checkout of the kubernetes-json-schema project, requires --validate-schemas",
+			},
+			&cli.StringFlag{
+				Name:  "run",
+				Usage: "only run test cases whose \"<spec title>/<test case title>\" matches this regular expression",
+			},
+			&cli.StringSliceFlag{
+				Name:  "tags",
+				Usage: "only run test cases with at least one of these tags",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude-tags",
+				Usage: "do not run test cases with any of these tags",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: 0,
+				Usage: "maximum duration of a test case including building dependencies, i.e. \"30s\", 0 disables the timeout",
+			},
+			&cli.BoolFlag{
+				Name:  "coverage",
+				Value: false,
+				Usage: "report which chart templates were never rendered by any test case",
+			},
+			&cli.Float64Flag{
+				Name:  "coverage-threshold",
+				Value: 0,
+				Usage: "fail if less than this percentage of templates is rendered, implies --coverage",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Value: false,
+				Usage: "watch spec files and charts and re-run affected specs on changes",
 			},
 			&cli.BoolFlag{
 				Name:  "version",
 				Value: false,
 				Usage: "print version information",
 			},
-		},
+		)...),
 		Action: func(cCtx *cli.Context) (err error) {
 			if cCtx.Bool("version") {
 				_, err = settings.Writer.Write([]byte(version))
 				return err
 			}
 
-			var specDir string
-			if cCtx.Args().Present() {
-				specDir = cCtx.Args().First()
-			} else {
-				specDir = defaultSpecDir
+			config, err := loadConfig(cCtx, settings, cCtx.App.Flags)
+			if err != nil {
+				return err
 			}
-			if err = validateSpecDirPath(specDir); err != nil {
+			specFiles, err := specFilesFromArgs(cCtx, config)
+			if err != nil {
 				return err
 			}
 			outputFormat := cCtx.String("output-format")
@@ -133,27 +333,88 @@ func createApp(settings cliSettings) (app *cli.App, err error) {
 				return err
 			}
 
-			specFiles, err := filepath.Glob(filepath.Join(specDir, specFileGlobPattern))
+			runSettings, err := renderSettingsFromFlags(cCtx, config)
 			if err != nil {
 				return err
 			}
-			result, err := settings.TestRunner.Run(specFiles)
-			if err != nil {
-				return err
+			var schemaValidator *helmspec.SchemaValidator
+			for _, name := range []string{"schema-kube-version", "schema-dir"} {
+				if cCtx.IsSet(name) && !cCtx.Bool("validate-schemas") {
+					return fmt.Errorf("--%v requires --validate-schemas", name)
+				}
+			}
+			if cCtx.Bool("validate-schemas") {
+				schemaValidator, err = helmspec.NewSchemaValidator(cCtx.String("schema-kube-version"), cCtx.String("schema-dir"))
+				if err != nil {
+					return err
+				}
 			}
+			filter := helmspec.TestCaseFilter{
+				Tags:        cCtx.StringSlice("tags"),
+				ExcludeTags: cCtx.StringSlice("exclude-tags"),
+			}
+			if run := cCtx.String("run"); run != "" {
+				if filter.Run, err = regexp.Compile(run); err != nil {
+					return fmt.Errorf("invalid --run expression: %w", err)
+				}
+			}
+			threshold := cCtx.Float64("coverage-threshold")
+			if threshold < 0 || threshold > 100 {
+				return fmt.Errorf("coverage threshold must be a percentage between 0 and 100, got %v", threshold)
+			}
+			runSettings.UpdateSnapshots = cCtx.Bool("update-snapshots")
+			runSettings.SchemaValidator = schemaValidator
+			runSettings.Filter = filter
+			runSettings.Timeout = cCtx.Duration("timeout")
+			runSettings.Coverage = cCtx.Bool("coverage") || threshold > 0
 			reportSettings := testreport.TestReportSettings{
 				OutputFormat: outputFormat,
 				UseColor:     !isColorDisabled(cCtx),
 				Verbose:      cCtx.Bool("verbose"),
 			}
+			if cCtx.Bool("watch") {
+				watcher := specWatcher{
+					specFiles: specFiles,
+					interval:  settings.WatchInterval,
+					run: func(ctx context.Context, specFiles []string) (helmspec.TestSuiteResult, error) {
+						return settings.TestRunner.Run(ctx, specFiles, runSettings)
+					},
+					report: func(result helmspec.TestSuiteResult, err error) {
+						if reportSettings.UseColor {
+							fmt.Fprint(settings.Writer, clearScreen)
+						}
+						if err == nil || isInterrupted(err) {
+							var report string
+							report, err = settings.TestReporter.Report(result, reportSettings)
+							fmt.Fprint(settings.Writer, report)
+						}
+						if err != nil {
+							fmt.Fprintf(settings.ErrWriter, "%v\n", err)
+						}
+					},
+				}
+				return watcher.Watch(cCtx.Context)
+			}
+			// the results of finished test cases are still reported after an interrupt
+			result, err := settings.TestRunner.Run(cCtx.Context, specFiles, runSettings)
+			interrupted := isInterrupted(err)
+			if err != nil && !interrupted {
+				return err
+			}
 			report, err := settings.TestReporter.Report(result, reportSettings)
 			if err != nil {
 				return err
 			}
 			fmt.Fprint(settings.Writer, report)
+			if interrupted {
+				return errors.New("test run interrupted")
+			}
 			if !result.Succeeded {
 				return errors.New("test suite failed")
 			}
+			if coverage := helmspec.TotalTemplateCoverage(result.Coverage); runSettings.Coverage && coverage < threshold {
+				return fmt.Errorf("template coverage of %.1f%% is below the threshold of %v%%", coverage, threshold)
+			}
 			return nil
 		},
 		Reader:         settings.Reader,
@@ -164,12 +425,19 @@ func createApp(settings cliSettings) (app *cli.App, err error) {
 	return app, nil
 }
 
+// returns true if a test run was cancelled, i.e. by SIGINT
+func isInterrupted(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
 func main() {
 	app, err := createApp(defaultSettings)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
-	if err = app.Run(os.Args); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err = app.RunContext(ctx, os.Args); err != nil {
 		log.Fatal(err.Error())
 	}
 }
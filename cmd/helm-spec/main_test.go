@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bujarmurati/helm-spec/internal/helmspec"
 	"github.com/bujarmurati/helm-spec/internal/testreport"
@@ -20,13 +24,16 @@ type errCapture struct {
 type mockTestRunner struct {
 	Result    helmspec.TestSuiteResult
 	SpecFiles []string
+	Settings  helmspec.TestRunSettings
 	HasRun    bool
+	Err       error
 }
 
-func (m *mockTestRunner) Run(specFiles []string) (r helmspec.TestSuiteResult, err error) {
+func (m *mockTestRunner) Run(_ context.Context, specFiles []string, settings helmspec.TestRunSettings) (r helmspec.TestSuiteResult, err error) {
 	m.SpecFiles = specFiles
+	m.Settings = settings
 	m.HasRun = true
-	return m.Result, nil
+	return m.Result, m.Err
 }
 
 type mockTestReporter struct {
@@ -85,6 +92,20 @@ func TestAcceptsSpecDirArg(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSpecDirsNamedLikeSubcommandsArePassedAsPaths(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "coverage"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "coverage", "foo_spec.yaml"), []byte("title: foo\n"), 0o644))
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { assert.NoError(t, os.Chdir(wd)) })
+	settings, err := testRun(t, []string{"helm-spec", "./coverage"})
+	assert.NoError(t, err)
+	assert.True(t, settings.TestRunner.(*mockTestRunner).HasRun)
+	assert.Equal(t, 1, len(settings.TestRunner.(*mockTestRunner).SpecFiles))
+}
+
 func TestValidatesSpecDirArg(t *testing.T) {
 	type testCase struct {
 		title          string
@@ -98,11 +119,6 @@ func TestValidatesSpecDirArg(t *testing.T) {
 			path:           "./foo",
 			errMsgFragment: "no such file or directory",
 		},
-		{
-			title:          "not a directory",
-			path:           "./main_test.go",
-			errMsgFragment: "not a directory",
-		},
 		{
 			title:          "directory without *_spec.yaml files",
 			path:           "./testdata",
@@ -123,6 +139,37 @@ func TestValidatesSpecDirArg(t *testing.T) {
 	}
 }
 
+func TestAcceptsMultipleSpecPaths(t *testing.T) {
+	args := []string{"helm-spec", "./testdata/specs", "./testdata/monorepo/charts/foo/specs/foo_test.yaml", "./testdata/specs/example_spec.yaml"}
+	settings, err := testRun(t, args)
+	assert.NoError(t, err)
+	expected := []string{"testdata/specs/example_spec.yaml", "./testdata/monorepo/charts/foo/specs/foo_test.yaml"}
+	assert.Equal(t, expected, settings.TestRunner.(*mockTestRunner).SpecFiles)
+}
+
+func TestRecursiveSpecDiscovery(t *testing.T) {
+	args := []string{"helm-spec", "--recursive", "./testdata/monorepo"}
+	settings, err := testRun(t, args)
+	assert.NoError(t, err)
+	expected := []string{"testdata/monorepo/charts/bar/specs/bar_spec.yaml", "testdata/monorepo/charts/foo/specs/foo_spec.yaml"}
+	assert.Equal(t, expected, settings.TestRunner.(*mockTestRunner).SpecFiles)
+
+	// without --recursive only the top-level directory is searched
+	_, err = testRun(t, []string{"helm-spec", "./testdata/monorepo"})
+	assert.ErrorContains(t, err, "no *_spec.yaml files")
+}
+
+func TestSpecFilePattern(t *testing.T) {
+	args := []string{"helm-spec", "--recursive", "--pattern", "*_test.yaml", "./testdata/monorepo"}
+	settings, err := testRun(t, args)
+	assert.NoError(t, err)
+	expected := []string{"testdata/monorepo/charts/foo/specs/foo_test.yaml"}
+	assert.Equal(t, expected, settings.TestRunner.(*mockTestRunner).SpecFiles)
+
+	_, err = testRun(t, []string{"helm-spec", "--pattern", "[", "./testdata/specs"})
+	assert.ErrorContains(t, err, "invalid spec file pattern")
+}
+
 func TestTestCommandExecutesTestRunner(t *testing.T) {
 	specDir, err := filepath.Abs("./testdata/specs")
 	assert.NoError(t, err)
@@ -192,6 +239,124 @@ func TestVerboseMode(t *testing.T) {
 	assert.True(t, reportSettings.Verbose)
 }
 
+func TestJobsDefaultToNumberOfCPUs(t *testing.T) {
+	specDir, err := filepath.Abs("./testdata/specs")
+	assert.NoError(t, err)
+	args := []string{"helm-spec", specDir}
+	settings, err := testRun(t, args)
+	assert.NoError(t, err)
+	assert.Equal(t, runtime.NumCPU(), settings.TestRunner.(*mockTestRunner).Settings.Jobs)
+}
+
+func TestJobsFlag(t *testing.T) {
+	specDir, err := filepath.Abs("./testdata/specs")
+	assert.NoError(t, err)
+	args := []string{"helm-spec", "--jobs", "3", specDir}
+	settings, err := testRun(t, args)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, settings.TestRunner.(*mockTestRunner).Settings.Jobs)
+
+	args = []string{"helm-spec", "--jobs", "0", specDir}
+	_, err = testRun(t, args)
+	assert.ErrorContains(t, err, "jobs must be a positive number")
+}
+
+func TestSkipDependencyBuildFlag(t *testing.T) {
+	specDir, err := filepath.Abs("./testdata/specs")
+	assert.NoError(t, err)
+	settings, err := testRun(t, []string{"helm-spec", specDir})
+	assert.NoError(t, err)
+	assert.False(t, settings.TestRunner.(*mockTestRunner).Settings.SkipDependencyBuild)
+	settings, err = testRun(t, []string{"helm-spec", "--skip-dependency-build", specDir})
+	assert.NoError(t, err)
+	assert.True(t, settings.TestRunner.(*mockTestRunner).Settings.SkipDependencyBuild)
+}
+
+func TestRendererFlag(t *testing.T) {
+	specDir, err := filepath.Abs("./testdata/specs")
+	assert.NoError(t, err)
+	settings, err := testRun(t, []string{"helm-spec", specDir})
+	assert.NoError(t, err)
+	assert.IsType(t, helmspec.CLIRenderer{}, settings.TestRunner.(*mockTestRunner).Settings.Renderer)
+	settings, err = testRun(t, []string{"helm-spec", "--renderer", "sdk", specDir})
+	assert.NoError(t, err)
+	assert.IsType(t, helmspec.SDKRenderer{}, settings.TestRunner.(*mockTestRunner).Settings.Renderer)
+	_, err = testRun(t, []string{"helm-spec", "--renderer", "foo", specDir})
+	assert.ErrorContains(t, err, "renderer must be one of")
+}
+
+func TestUpdateSnapshotsFlag(t *testing.T) {
+	specDir, err := filepath.Abs("./testdata/specs")
+	assert.NoError(t, err)
+	settings, err := testRun(t, []string{"helm-spec", specDir})
+	assert.NoError(t, err)
+	assert.False(t, settings.TestRunner.(*mockTestRunner).Settings.UpdateSnapshots)
+	settings, err = testRun(t, []string{"helm-spec", "--update-snapshots", specDir})
+	assert.NoError(t, err)
+	assert.True(t, settings.TestRunner.(*mockTestRunner).Settings.UpdateSnapshots)
+}
+
+func TestValidateSchemasFlag(t *testing.T) {
+	specDir, err := filepath.Abs("./testdata/specs")
+	assert.NoError(t, err)
+	settings, err := testRun(t, []string{"helm-spec", specDir})
+	assert.NoError(t, err)
+	assert.Nil(t, settings.TestRunner.(*mockTestRunner).Settings.SchemaValidator)
+	settings, err = testRun(t, []string{"helm-spec", "--validate-schemas", specDir})
+	assert.NoError(t, err)
+	assert.NotNil(t, settings.TestRunner.(*mockTestRunner).Settings.SchemaValidator)
+	_, err = testRun(t, []string{"helm-spec", "--validate-schemas", "--schema-kube-version", "1.10.0", specDir})
+	assert.ErrorContains(t, err, "no type schemas")
+	_, err = testRun(t, []string{"helm-spec", "--schema-kube-version", "1.25.0", specDir})
+	assert.ErrorContains(t, err, "--schema-kube-version requires --validate-schemas")
+	_, err = testRun(t, []string{"helm-spec", "--schema-dir", specDir, specDir})
+	assert.ErrorContains(t, err, "--schema-dir requires --validate-schemas")
+}
+
+func TestFilterFlags(t *testing.T) {
+	specDir, err := filepath.Abs("./testdata/specs")
+	assert.NoError(t, err)
+	args := []string{"helm-spec", "--run", "ingress$", "--tags", "a", "--tags", "b", "--exclude-tags", "slow", specDir}
+	settings, err := testRun(t, args)
+	assert.NoError(t, err)
+	filter := settings.TestRunner.(*mockTestRunner).Settings.Filter
+	assert.Equal(t, "ingress$", filter.Run.String())
+	assert.Equal(t, []string{"a", "b"}, filter.Tags)
+	assert.Equal(t, []string{"slow"}, filter.ExcludeTags)
+
+	_, err = testRun(t, []string{"helm-spec", "--run", "(", specDir})
+	assert.ErrorContains(t, err, "invalid --run expression")
+}
+
+func TestTimeoutFlag(t *testing.T) {
+	settings, err := testRun(t, []string{"helm-spec", "--timeout", "30s", "./testdata/specs"})
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, settings.TestRunner.(*mockTestRunner).Settings.Timeout)
+}
+
+func TestInterruptedRunIsStillReported(t *testing.T) {
+	settings := newTestCLISettings()
+	settings.TestRunner.(*mockTestRunner).Err = context.Canceled
+	app, err := createApp(settings.cliSettings)
+	assert.NoError(t, err)
+	err = app.Run([]string{"helm-spec", "./testdata/specs"})
+	assert.EqualError(t, err, "test run interrupted")
+	assert.Equal(t, "output", settings.Writer.(*strings.Builder).String())
+}
+
+func TestWatchFlag(t *testing.T) {
+	settings := newTestCLISettings()
+	settings.WatchInterval = 10 * time.Millisecond
+	app, err := createApp(settings.cliSettings)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = app.RunContext(ctx, []string{"helm-spec", "--watch", "--no-color", "./testdata/specs"})
+	assert.NoError(t, err)
+	assert.True(t, settings.TestRunner.(*mockTestRunner).HasRun)
+	assert.Equal(t, "output", settings.Writer.(*strings.Builder).String())
+}
+
 func TestVersion(t *testing.T) {
 	args := []string{"helm-spec", "--version"}
 	version = "0.1.0"
@@ -200,3 +365,59 @@ func TestVersion(t *testing.T) {
 	output := settings.cliSettings.Writer.(*strings.Builder).String()
 	assert.Contains(t, output, version)
 }
+
+func TestCoverageFlags(t *testing.T) {
+	settings, err := testRun(t, []string{"helm-spec", "--coverage", "./testdata/specs"})
+	assert.NoError(t, err)
+	assert.True(t, settings.TestRunner.(*mockTestRunner).Settings.Coverage)
+
+	settings = newTestCLISettings()
+	runner := settings.TestRunner.(*mockTestRunner)
+	runner.Result.Coverage = []helmspec.TemplateCoverage{{Templates: 4, Rendered: []string{"templates/a.yaml"}}}
+	app, err := createApp(settings.cliSettings)
+	assert.NoError(t, err)
+	err = app.Run([]string{"helm-spec", "--coverage-threshold", "50", "./testdata/specs"})
+	assert.EqualError(t, err, "template coverage of 25.0% is below the threshold of 50%")
+	assert.True(t, runner.Settings.Coverage)
+	assert.NoError(t, app.Run([]string{"helm-spec", "--coverage-threshold", "25", "./testdata/specs"}))
+
+	_, err = testRun(t, []string{"helm-spec", "--coverage-threshold", "101", "./testdata/specs"})
+	assert.ErrorContains(t, err, "coverage threshold must be a percentage")
+}
+
+func TestCoverageValuesCommand(t *testing.T) {
+	specFile := "../../internal/helmspec/testdata/charts/example/specs/defaults_spec.yaml"
+	settings, err := testRun(t, []string{"helm-spec", "coverage", "values", "-o", "yaml", specFile})
+	assert.NoError(t, err)
+	output := settings.Writer.(*strings.Builder).String()
+	assert.Contains(t, output, "path: image.tag")
+	assert.Contains(t, output, "ineffective:\n  - image.pullPolicy")
+	assert.False(t, settings.TestRunner.(*mockTestRunner).HasRun)
+
+	_, err = testRun(t, []string{"helm-spec", "coverage", "values", "-j", "0", specFile})
+	assert.ErrorContains(t, err, "jobs must be a positive number")
+}
+
+func TestValidateCommand(t *testing.T) {
+	specFile := "../../internal/helmspec/testdata/charts/example/specs/successful_spec.yaml"
+	settings, err := testRun(t, []string{"helm-spec", "validate", specFile})
+	assert.NoError(t, err)
+	assert.Equal(t, "1 spec files are valid\n", settings.Writer.(*strings.Builder).String())
+	assert.False(t, settings.TestRunner.(*mockTestRunner).HasRun)
+
+	invalidSpec := filepath.Join(t.TempDir(), "invalid_spec.yaml")
+	assert.NoError(t, os.WriteFile(invalidSpec, []byte("title: invalid\ntestCase: []\n"), 0o644))
+	_, err = testRun(t, []string{"helm-spec", "validate", specFile, invalidSpec})
+	var invalid *helmspec.InvalidSpecsError
+	assert.ErrorAs(t, err, &invalid)
+	assert.Equal(t, []helmspec.SpecProblem{{File: invalidSpec, Line: 2, Message: "unknown key `testCase`"}}, invalid.Problems)
+}
+
+func TestSchemaCommand(t *testing.T) {
+	settings, err := testRun(t, []string{"helm-spec", "schema"})
+	assert.NoError(t, err)
+	schema := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(settings.Writer.(*strings.Builder).String()), &schema))
+	assert.Equal(t, "#/definitions/HelmSpec", schema["$ref"])
+	assert.False(t, settings.TestRunner.(*mockTestRunner).HasRun)
+}
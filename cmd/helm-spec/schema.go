@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/urfave/cli/v2"
+)
+
+// prints the json schema of spec files
+func schemaCommand(settings cliSettings) *cli.Command {
+	return &cli.Command{
+		Name:  "schema",
+		Usage: "print the json schema of spec files, i.e. for the yaml language server",
+		Action: func(cCtx *cli.Context) error {
+			schema, err := helmspec.SpecSchema()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(settings.Writer, string(schema))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/urfave/cli/v2"
+)
+
+// checks spec files for problems without rendering any charts
+func validateCommand(settings cliSettings) *cli.Command {
+	return &cli.Command{
+		Name:      "validate",
+		Usage:     "check spec files for unknown keys, invalid queries, duplicate titles and empty assertions",
+		ArgsUsage: "<spec files or directories (default: \"./specs\")>",
+		Flags:     specFileFlags(),
+		Action: func(cCtx *cli.Context) error {
+			config, err := loadConfig(cCtx, settings, cCtx.Command.Flags)
+			if err != nil {
+				return err
+			}
+			specFiles, err := specFilesFromArgs(cCtx, config)
+			if err != nil {
+				return err
+			}
+			if err = helmspec.ValidateSpecFiles(specFiles); err != nil {
+				return err
+			}
+			fmt.Fprintf(settings.Writer, "%v spec files are valid\n", len(specFiles))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+)
+
+// modification time and size of a watched file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// returns true for files that are written by test runs themselves
+// and must not trigger another run
+func isGeneratedFile(path string) bool {
+	parts := strings.Split(filepath.ToSlash(path), "/")
+	for idx, part := range parts {
+		if part == "__snapshots__" {
+			return true
+		}
+		// `helm dependency build` downloads dependencies to a temporary directory first
+		if part == "tmpcharts" {
+			return true
+		}
+		// packaged dependencies are rewritten by `helm dependency build`
+		if part == "charts" && idx == len(parts)-2 && strings.HasSuffix(path, ".tgz") {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the state of a file or of all files below a directory
+func scanFiles(root string) (states map[string]fileState) {
+	states = map[string]fileState{}
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isGeneratedFile(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return states
+}
+
+func statesEqual(a map[string]fileState, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, state := range a {
+		if other, ok := b[path]; !ok || other != state {
+			return false
+		}
+	}
+	return true
+}
+
+// re-runs specs when their spec file or the chart they reference changes
+type specWatcher struct {
+	specFiles []string
+	interval  time.Duration
+	// runs the given spec files
+	run func(ctx context.Context, specFiles []string) (helmspec.TestSuiteResult, error)
+	// reports the results of all spec files
+	report func(result helmspec.TestSuiteResult, err error)
+
+	results map[string]helmspec.SpecResult
+	states  map[string]map[string]fileState
+	// watched paths of each spec file, only reloaded when the spec file changes
+	paths map[string]watchedSpec
+}
+
+// the paths a spec depends on as of a state of its spec file
+type watchedSpec struct {
+	state fileState
+	paths []string
+}
+
+// returns the spec file, the chart directory and the values files outside the chart of a spec
+func watchedPaths(specFile string) (paths []string, err error) {
+	paths = []string{specFile}
+	spec, err := helmspec.NewSpec(specFile)
+	if err != nil {
+		return paths, err
+	}
+	paths = append(paths, spec.ChartPath)
+	seen := map[string]bool{}
+	valuesFiles := append([]string{}, spec.Defaults.ValuesFiles...)
+	for _, testCase := range spec.TestCases {
+		valuesFiles = append(valuesFiles, testCase.Render.ValuesFiles...)
+	}
+	for _, f := range valuesFiles {
+		if rel, err := filepath.Rel(spec.ChartPath, f); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if !seen[f] {
+			seen[f] = true
+			paths = append(paths, f)
+		}
+	}
+	return paths, nil
+}
+
+// returns the watched paths of a spec, loading the spec only if its spec file changed
+func (w *specWatcher) cachedWatchedPaths(specFile string) []string {
+	state := scanFiles(specFile)[specFile]
+	if cached, ok := w.paths[specFile]; ok && cached.state == state {
+		return cached.paths
+	}
+	paths, err := watchedPaths(specFile)
+	// specs that fail to load are loaded again, i.e. once a missing values file exists
+	if err == nil {
+		w.paths[specFile] = watchedSpec{state: state, paths: paths}
+	}
+	return paths
+}
+
+// scans the watched paths of all specs and returns the specs with changes
+func (w *specWatcher) changedSpecs() (changed []string) {
+	scanned := map[string]map[string]fileState{}
+	for _, specFile := range w.specFiles {
+		isChanged := false
+		for _, path := range w.cachedWatchedPaths(specFile) {
+			states, ok := scanned[path]
+			if !ok {
+				states = scanFiles(path)
+				scanned[path] = states
+			}
+			previous, ok := w.states[path]
+			if !ok || !statesEqual(previous, states) {
+				isChanged = true
+			}
+		}
+		if isChanged {
+			changed = append(changed, specFile)
+		}
+	}
+	w.states = scanned
+	return changed
+}
+
+// runs the changed specs and reports the results of all specs
+func (w *specWatcher) runChanged(ctx context.Context, changed []string) {
+	result, err := w.run(ctx, changed)
+	if err == nil || isInterrupted(err) {
+		for idx, specFile := range changed {
+			if idx < len(result.SpecResults) {
+				w.results[specFile] = result.SpecResults[idx]
+			}
+		}
+	}
+	suite := helmspec.TestSuiteResult{Succeeded: err == nil}
+	for _, specFile := range w.specFiles {
+		if r, ok := w.results[specFile]; ok {
+			suite.Succeeded = suite.Succeeded && r.Succeeded
+			suite.SpecResults = append(suite.SpecResults, r)
+		}
+	}
+	w.report(suite, err)
+}
+
+// runs all specs and then re-runs affected specs on changes until ctx is done
+func (w *specWatcher) Watch(ctx context.Context) error {
+	w.results = map[string]helmspec.SpecResult{}
+	w.states = map[string]map[string]fileState{}
+	w.paths = map[string]watchedSpec{}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if changed := w.changedSpecs(); len(changed) > 0 {
+			w.runChanged(ctx, changed)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeWatchedFile(t *testing.T, path string, content string) {
+	t.Helper()
+	assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestIsGeneratedFile(t *testing.T) {
+	assert.True(t, isGeneratedFile("specs/__snapshots__/foo/bar.yaml"))
+	assert.True(t, isGeneratedFile("chart/charts/dep-1.0.0.tgz"))
+	assert.True(t, isGeneratedFile("chart/charts/tmpcharts/dep-1.0.0.tgz"))
+	assert.False(t, isGeneratedFile("chart/charts/dep/values.yaml"))
+	assert.False(t, isGeneratedFile("chart/templates/deployment.yaml"))
+}
+
+func TestWatchRerunsAffectedSpecs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"foo", "bar"} {
+		writeWatchedFile(t, filepath.Join(dir, name, "Chart.yaml"), "name: "+name+"\n")
+		writeWatchedFile(t, filepath.Join(dir, name+"_spec.yaml"), "title: "+name+"\nchartPath: "+name+"\n")
+	}
+	fooSpec := filepath.Join(dir, "foo_spec.yaml")
+	barSpec := filepath.Join(dir, "bar_spec.yaml")
+
+	mu := sync.Mutex{}
+	runs := [][]string{}
+	reports := []helmspec.TestSuiteResult{}
+	watcher := specWatcher{
+		specFiles: []string{fooSpec, barSpec},
+		interval:  10 * time.Millisecond,
+		run: func(_ context.Context, specFiles []string) (result helmspec.TestSuiteResult, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs = append(runs, specFiles)
+			result.Succeeded = true
+			for _, f := range specFiles {
+				result.SpecResults = append(result.SpecResults, helmspec.SpecResult{Title: filepath.Base(f), Succeeded: true})
+			}
+			return result, nil
+		},
+		report: func(result helmspec.TestSuiteResult, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, result)
+		},
+	}
+	runCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(runs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- watcher.Watch(ctx) }()
+
+	assert.Eventually(t, func() bool { return runCount() == 1 }, time.Second, 5*time.Millisecond)
+	// ensure the modification time changes even on coarse file systems
+	later := time.Now().Add(time.Minute)
+	template := filepath.Join(dir, "bar", "templates", "cm.yaml")
+	writeWatchedFile(t, template, "kind: ConfigMap\n")
+	assert.NoError(t, os.Chtimes(template, later, later))
+	assert.Eventually(t, func() bool { return runCount() == 2 }, time.Second, 5*time.Millisecond)
+	// snapshots written by a run do not trigger another run
+	writeWatchedFile(t, filepath.Join(dir, "__snapshots__", "foo", "case.yaml"), "kind: ConfigMap\n")
+	time.Sleep(50 * time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, [][]string{{fooSpec, barSpec}, {barSpec}}, runs)
+	// the report always contains the latest results of all specs
+	assert.Len(t, reports, 2)
+	assert.Len(t, reports[1].SpecResults, 2)
+	assert.Equal(t, "foo_spec.yaml", reports[1].SpecResults[0].Title)
+}
+
+func TestWatchedPathsIncludeValuesFilesOutsideTheChart(t *testing.T) {
+	dir := t.TempDir()
+	writeWatchedFile(t, filepath.Join(dir, "foo", "Chart.yaml"), "name: foo\n")
+	writeWatchedFile(t, filepath.Join(dir, "foo", "ci-values.yaml"), "replicaCount: 2\n")
+	writeWatchedFile(t, filepath.Join(dir, "values", "prod.yaml"), "replicaCount: 3\n")
+	specFile := filepath.Join(dir, "foo_spec.yaml")
+	writeWatchedFile(t, specFile, `title: foo
+chartPath: foo
+defaults:
+  valuesFiles: [foo/ci-values.yaml]
+testCases:
+- title: prod
+  render:
+    valuesFiles: [values/prod.yaml]
+`)
+	paths, err := watchedPaths(specFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{specFile, filepath.Join(dir, "foo"), filepath.Join(dir, "values", "prod.yaml")}, paths)
+}
+
+func TestWatchedPathsAreCachedUntilTheSpecFileChanges(t *testing.T) {
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "foo_spec.yaml")
+	writeWatchedFile(t, specFile, "title: foo\nchartPath: foo\n")
+	watcher := specWatcher{paths: map[string]watchedSpec{}}
+	assert.Equal(t, []string{specFile, filepath.Join(dir, "foo")}, watcher.cachedWatchedPaths(specFile))
+	// a cached entry is used as long as the spec file is unchanged
+	state := watcher.paths[specFile].state
+	watcher.paths[specFile] = watchedSpec{state: state, paths: []string{"cached"}}
+	assert.Equal(t, []string{"cached"}, watcher.cachedWatchedPaths(specFile))
+	later := time.Now().Add(time.Minute)
+	writeWatchedFile(t, specFile, "title: foo\nchartPath: bar\n")
+	assert.NoError(t, os.Chtimes(specFile, later, later))
+	assert.Equal(t, []string{specFile, filepath.Join(dir, "bar")}, watcher.cachedWatchedPaths(specFile))
+}
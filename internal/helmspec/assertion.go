@@ -1,6 +1,9 @@
 package helmspec
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/mikefarah/yq/v4/pkg/yqlib"
@@ -11,12 +14,60 @@ import (
 type Assertion struct {
 	// human-readable description of what the assertion tests
 	Description string
+	// restricts the query to the rendered documents matching the selector
+	Document *DocumentSelector `json:",omitempty"`
 	// a [yq] query to perform against the rendering output
-	// The output will contain all rendered manifests with document separators
+	// The output will contain all rendered manifests with document separators,
+	// or only the documents matching `document` if set
 	// [yq]: https://mikefarah.gitbook.io/yq/
 	Query string
-	// a string that the output of the `yq` query must equal in order for the test to pass
+	// a string that the output of the `yq` query is compared to in order for the test to pass.
+	// A yaml map or list is compared semantically, ignoring key order, quoting and indentation
 	ExpectedResult string
+	// how the output of the `yq` query is compared to the expected result,
+	// one of the AllowedOperators, defaults to `equals`
+	Operator string
+	// set if the expected result was given as a yaml map or list instead of a string
+	Structured bool `json:"-"`
+}
+
+// accepts strings, scalars and structured yaml values for the expected result
+func (a *Assertion) UnmarshalJSON(data []byte) error {
+	type assertion Assertion
+	aux := struct {
+		*assertion
+		ExpectedResult json.RawMessage
+	}{assertion: (*assertion)(a)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	raw := bytes.TrimSpace(aux.ExpectedResult)
+	switch {
+	case len(raw) == 0 || string(raw) == "null":
+		a.ExpectedResult = ""
+	case raw[0] == '"':
+		return json.Unmarshal(raw, &a.ExpectedResult)
+	case raw[0] == '{' || raw[0] == '[':
+		canonical, err := canonicalYAML(string(raw))
+		if err != nil {
+			return fmt.Errorf("invalid structured expected result: %w", err)
+		}
+		a.ExpectedResult = canonical
+		a.Structured = true
+	default:
+		// numbers and booleans as json prints them, NewSpec restores
+		// the text written in the spec file, i.e. `1.10` instead of `1.1`
+		a.ExpectedResult = string(raw)
+	}
+	return nil
+}
+
+// returns the operator of the assertion, falling back to `equals`
+func (a Assertion) ResolvedOperator() string {
+	if a.Operator == "" {
+		return OperatorEquals
+	}
+	return a.Operator
 }
 
 func EvalYQ(expression string, input string) (result string, err error) {
@@ -30,20 +81,54 @@ type AssertionResult struct {
 	Assertion    Assertion `json:"assertion"`
 	Succeeded    bool      `json:"succeeded"`
 	ActualResult string    `json:"actualResult"`
-	Error        error     `json:"error"`
+	// unified diff between the expected and actual result for structured
+	// comparisons and failed multi-line equality checks
+	Diff  string `json:"diff,omitempty"`
+	Error error  `json:"error"`
 }
 
 func (a Assertion) Evaluate(manifest string) (result AssertionResult) {
+	result.Assertion = a
+	// always report which operator was used
+	result.Assertion.Operator = a.ResolvedOperator()
+	if a.Document != nil {
+		selected, err := a.Document.Select(manifest)
+		if err != nil {
+			result.Error = err
+			return result
+		}
+		manifest = selected
+	}
 	actualResult, err := EvalYQ(a.Query, manifest)
 	result.ActualResult = strings.TrimSpace(actualResult)
-	result.Error = err
-	result.Assertion = a
-	result.Succeeded = result.ActualResult == a.ExpectedResult
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	if a.Structured {
+		return a.evaluateStructured(result)
+	}
+	result.Succeeded, result.Error = compare(a.ResolvedOperator(), actualResult, a.ExpectedResult)
+	if !result.Succeeded && result.Error == nil && a.ResolvedOperator() == OperatorEquals && isMultiline(a.ExpectedResult, result.ActualResult) {
+		result.Diff = UnifiedDiff(strings.TrimSpace(a.ExpectedResult)+"\n", result.ActualResult+"\n", "expected", "actual")
+	}
 	return result
 }
 
+// returns true if any of the texts spans multiple lines
+func isMultiline(texts ...string) bool {
+	for _, text := range texts {
+		if strings.Contains(strings.TrimSpace(text), "\n") {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	// quiet down the noisy yq logger
 	logger := yqlib.GetLogger()
 	logging.SetLevel(logging.ERROR, logger.Module)
+	// queries are parsed without evaluating them when validating spec files
+	yqlib.InitExpressionParser()
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
 )
 
 func TestAssertionEvaluation(t *testing.T) {
@@ -58,3 +59,159 @@ foo: bar
 		})
 	}
 }
+
+func TestAssertionOperators(t *testing.T) {
+	type testCase struct {
+		operator       string
+		query          string
+		expectedResult string
+		succeeded      bool
+		shouldError    bool
+	}
+
+	testCases := []testCase{
+		{operator: OperatorEquals, query: ".foo", expectedResult: "bar", succeeded: true},
+		{operator: OperatorNotEquals, query: ".foo", expectedResult: "bar", succeeded: false},
+		{operator: OperatorNotEquals, query: ".foo", expectedResult: "baz", succeeded: true},
+		{operator: OperatorContains, query: ".image", expectedResult: "nginx:", succeeded: true},
+		{operator: OperatorNotContains, query: ".image", expectedResult: "latest", succeeded: true},
+		{operator: OperatorMatches, query: ".image", expectedResult: `^nginx:\d+\.\d+$`, succeeded: true},
+		{operator: OperatorMatches, query: ".image", expectedResult: `(`, shouldError: true},
+		{operator: OperatorIsEmpty, query: ".list", succeeded: true},
+		{operator: OperatorIsEmpty, query: ".missing", succeeded: true},
+		{operator: OperatorIsEmpty, query: ".foo", succeeded: false},
+		{operator: OperatorIsNull, query: ".missing", succeeded: true},
+		{operator: OperatorIsNull, query: ".list", succeeded: false},
+		{operator: OperatorIsNull, query: ".emptyString", succeeded: false},
+		{operator: OperatorIsNull, query: ".nothing", succeeded: true},
+		{operator: OperatorIsNull, query: `select(.foo == "baz")`, succeeded: true},
+		{operator: OperatorIsEmpty, query: ".emptyString", succeeded: true},
+		{operator: OperatorExists, query: ".emptyString", succeeded: true},
+		{operator: OperatorExists, query: ".nothing", succeeded: false},
+		{operator: OperatorExists, query: ".foo", succeeded: true},
+		{operator: OperatorExists, query: ".missing", succeeded: false},
+		{operator: OperatorGreaterThan, query: ".replicas", expectedResult: "2", succeeded: true},
+		{operator: OperatorGreaterThan, query: ".replicas", expectedResult: "3", succeeded: false},
+		{operator: OperatorGreaterThanOrEqual, query: ".replicas", expectedResult: "3", succeeded: true},
+		{operator: OperatorLessThan, query: ".replicas", expectedResult: "3.5", succeeded: true},
+		{operator: OperatorLessThanOrEqual, query: ".replicas", expectedResult: "2", succeeded: false},
+		{operator: OperatorLessThan, query: ".foo", expectedResult: "2", shouldError: true},
+		{operator: "unknown", query: ".foo", expectedResult: "bar", shouldError: true},
+	}
+
+	manifest := `
+foo: bar
+image: nginx:1.23
+list: []
+replicas: 3
+emptyString: ""
+nothing: ~
+`
+
+	for _, c := range testCases {
+		t.Run(c.operator+" "+c.query+" "+c.expectedResult, func(t *testing.T) {
+			a := Assertion{
+				Description:    "description",
+				Query:          c.query,
+				ExpectedResult: c.expectedResult,
+				Operator:       c.operator,
+			}
+			result := a.Evaluate(manifest)
+			if c.shouldError {
+				assert.Error(t, result.Error)
+				assert.False(t, result.Succeeded)
+			} else {
+				assert.NoError(t, result.Error)
+				assert.Equal(t, c.succeeded, result.Succeeded)
+			}
+		})
+	}
+}
+
+func TestAssertionOperatorDefaultsToEquals(t *testing.T) {
+	result := Assertion{Query: ".foo", ExpectedResult: "bar"}.Evaluate("foo: bar")
+	assert.True(t, result.Succeeded)
+	assert.Equal(t, OperatorEquals, result.Assertion.Operator)
+}
+
+func TestStructuredExpectedResult(t *testing.T) {
+	spec := `
+description: labels should be set
+query: .metadata.labels
+expectedResult:
+  b: "2"
+  'a': one
+`
+	a := Assertion{}
+	assert.NoError(t, yaml.Unmarshal([]byte(spec), &a))
+	assert.True(t, a.Structured)
+	assert.Equal(t, "a: one\nb: \"2\"", a.ExpectedResult)
+
+	result := a.Evaluate(`
+metadata:
+  labels:
+    b: '2'
+    a: "one"
+`)
+	assert.NoError(t, result.Error)
+	assert.True(t, result.Succeeded)
+	assert.Empty(t, result.Diff)
+
+	result = a.Evaluate(`
+metadata:
+  labels:
+    a: one
+    b: 3
+`)
+	assert.NoError(t, result.Error)
+	assert.False(t, result.Succeeded)
+	assert.Contains(t, result.Diff, "-b: \"2\"")
+	assert.Contains(t, result.Diff, "+b: 3")
+
+	report, err := yaml.Marshal(result)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(report), "Structured")
+}
+
+func TestMultilineMismatchesHaveDiff(t *testing.T) {
+	manifest := `
+spec:
+  containers:
+  - name: app
+    image: app:1.0.0
+`
+	a := Assertion{Query: ".spec.containers[0]", ExpectedResult: "name: app\nimage: app:2.0.0\n"}
+	result := a.Evaluate(manifest)
+	assert.False(t, result.Succeeded)
+	assert.Contains(t, result.Diff, "-image: app:2.0.0")
+	assert.Contains(t, result.Diff, "+image: app:1.0.0")
+	assert.NotContains(t, result.Diff, "-name: app")
+
+	a = Assertion{Query: ".spec.containers[0].name", ExpectedResult: "web"}
+	result = a.Evaluate(manifest)
+	assert.False(t, result.Succeeded)
+	assert.Empty(t, result.Diff)
+
+	a = Assertion{Query: ".spec.containers[0]", Operator: OperatorContains, ExpectedResult: "image: web\nname: web"}
+	result = a.Evaluate(manifest)
+	assert.False(t, result.Succeeded)
+	assert.Empty(t, result.Diff)
+}
+
+func TestScalarExpectedResults(t *testing.T) {
+	spec := `
+- query: .replicas
+  expectedResult: 3
+- query: .enabled
+  expectedResult: true
+- query: .name
+  expectedResult: foo
+`
+	assertions := []Assertion{}
+	assert.NoError(t, yaml.Unmarshal([]byte(spec), &assertions))
+	manifest := "replicas: 3\nenabled: true\nname: foo\n"
+	for _, a := range assertions {
+		assert.False(t, a.Structured)
+		assert.True(t, a.Evaluate(manifest).Succeeded, a.Query)
+	}
+}
@@ -0,0 +1,117 @@
+package helmspec
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// which templates of a chart produced output in at least one test case
+type TemplateCoverage struct {
+	ChartPath string `json:"chartPath"`
+	// number of templates that can produce output
+	Templates int `json:"templates"`
+	// templates that were rendered, relative to the chart directory
+	Rendered []string `json:"rendered"`
+	// templates that were never rendered, relative to the chart directory
+	Unrendered []string `json:"unrendered"`
+	// share of rendered templates in percent
+	Percentage float64 `json:"percentage"`
+}
+
+// returns the templates of a chart that can produce output, relative to the chart
+// directory. Partials starting with `_` and NOTES.txt are not rendered to manifests
+func chartTemplates(chartPath string) (templates []string, err error) {
+	templatesDir := filepath.Join(chartPath, "templates")
+	err = filepath.WalkDir(templatesDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), "_") || d.Name() == "NOTES.txt" {
+			return nil
+		}
+		rel, err := filepath.Rel(chartPath, p)
+		if err != nil {
+			return err
+		}
+		templates = append(templates, filepath.ToSlash(rel))
+		return nil
+	})
+	return templates, err
+}
+
+// returns the template of a `# Source:` comment relative to the chart directory,
+// i.e. `templates/service.yaml` for `example/templates/service.yaml`.
+// Templates of subcharts are ignored
+func sourceTemplate(source string) (template string, ok bool) {
+	parts := strings.SplitN(source, "/", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[1], "templates/") {
+		return "", false
+	}
+	return path.Clean(parts[1]), true
+}
+
+// computes the template coverage of every chart from the rendered manifests
+// of all test cases that were executed without errors
+func ComputeTemplateCoverage(result TestSuiteResult) (coverage []TemplateCoverage, err error) {
+	charts := []string{}
+	rendered := map[string]map[string]bool{}
+	for _, spec := range result.SpecResults {
+		if _, ok := rendered[spec.ChartPath]; !ok {
+			charts = append(charts, spec.ChartPath)
+			rendered[spec.ChartPath] = map[string]bool{}
+		}
+		for _, testCase := range spec.TestCaseResults {
+			if testCase.Skipped || testCase.Error != nil {
+				continue
+			}
+			for _, document := range SplitManifest(testCase.Manifest) {
+				if template, ok := sourceTemplate(document.Source); ok {
+					rendered[spec.ChartPath][template] = true
+				}
+			}
+		}
+	}
+	for _, chartPath := range charts {
+		templates, err := chartTemplates(chartPath)
+		if err != nil {
+			return coverage, err
+		}
+		c := TemplateCoverage{
+			ChartPath:  chartPath,
+			Templates:  len(templates),
+			Rendered:   []string{},
+			Unrendered: []string{},
+			Percentage: 100,
+		}
+		for _, template := range templates {
+			if rendered[chartPath][template] {
+				c.Rendered = append(c.Rendered, template)
+			} else {
+				c.Unrendered = append(c.Unrendered, template)
+			}
+		}
+		sort.Strings(c.Rendered)
+		sort.Strings(c.Unrendered)
+		if c.Templates > 0 {
+			c.Percentage = 100 * float64(len(c.Rendered)) / float64(c.Templates)
+		}
+		coverage = append(coverage, c)
+	}
+	return coverage, nil
+}
+
+// returns the share of rendered templates across all charts in percent
+func TotalTemplateCoverage(coverage []TemplateCoverage) float64 {
+	templates, rendered := 0, 0
+	for _, c := range coverage {
+		templates += c.Templates
+		rendered += len(c.Rendered)
+	}
+	if templates == 0 {
+		return 100
+	}
+	return 100 * float64(rendered) / float64(templates)
+}
@@ -0,0 +1,69 @@
+package helmspec
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceTemplate(t *testing.T) {
+	template, ok := sourceTemplate("example/templates/service.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, "templates/service.yaml", template)
+	template, ok = sourceTemplate("example/templates/tests/test-connection.yaml")
+	assert.True(t, ok)
+	assert.Equal(t, "templates/tests/test-connection.yaml", template)
+	_, ok = sourceTemplate("example/charts/sub/templates/service.yaml")
+	assert.False(t, ok)
+	_, ok = sourceTemplate("")
+	assert.False(t, ok)
+}
+
+func TestComputeTemplateCoverage(t *testing.T) {
+	chartPath, err := filepath.Abs("./testdata/charts/example")
+	assert.NoError(t, err)
+	result := TestSuiteResult{SpecResults: []SpecResult{{
+		ChartPath: chartPath,
+		TestCaseResults: []TestCaseResult{
+			{Manifest: "---\n# Source: example/templates/service.yaml\nkind: Service\n"},
+			{Manifest: "---\n# Source: example/templates/deployment.yaml\nkind: Deployment\n"},
+			// skipped and failed test cases do not count
+			{Skipped: true, Manifest: "---\n# Source: example/templates/hpa.yaml\nkind: HorizontalPodAutoscaler\n"},
+			{Error: errors.New("boom"), Manifest: "---\n# Source: example/templates/ingress.yaml\nkind: Ingress\n"},
+		},
+	}}}
+	coverage, err := ComputeTemplateCoverage(result)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(coverage))
+	assert.Equal(t, chartPath, coverage[0].ChartPath)
+	assert.Equal(t, 6, coverage[0].Templates)
+	assert.Equal(t, []string{"templates/deployment.yaml", "templates/service.yaml"}, coverage[0].Rendered)
+	assert.Equal(t, []string{
+		"templates/hpa.yaml",
+		"templates/ingress.yaml",
+		"templates/serviceaccount.yaml",
+		"templates/tests/test-connection.yaml",
+	}, coverage[0].Unrendered)
+	assert.InDelta(t, 33.3, coverage[0].Percentage, 0.1)
+	assert.InDelta(t, 33.3, TotalTemplateCoverage(coverage), 0.1)
+}
+
+func TestHelmTestRunnerComputesCoverage(t *testing.T) {
+	specFiles := []string{"./testdata/charts/example/specs/successful_spec.yaml"}
+	result, err := HelmTestRunner{}.Run(context.Background(), specFiles, TestRunSettings{Coverage: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(result.Coverage))
+	assert.Contains(t, result.Coverage[0].Rendered, "templates/ingress.yaml")
+	assert.Contains(t, result.Coverage[0].Unrendered, "templates/hpa.yaml")
+
+	result, err = HelmTestRunner{}.Run(context.Background(), specFiles, TestRunSettings{})
+	assert.NoError(t, err)
+	assert.Nil(t, result.Coverage)
+}
+
+func TestTotalTemplateCoverageWithoutTemplates(t *testing.T) {
+	assert.Equal(t, float64(100), TotalTemplateCoverage(nil))
+}
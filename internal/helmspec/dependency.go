@@ -0,0 +1,119 @@
+package helmspec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// implemented by renderers to build the dependencies of a chart the way they render it
+type DependencyBuilder interface {
+	BuildDependencies(ctx context.Context, chartPath string) error
+}
+
+// runs `helm dependency build` for a chart with the given helm binary
+func BuildDependencies(ctx context.Context, helmBinary string, chartPath string) error {
+	helmDepBuildArgs := []string{"dependency", "build", chartPath}
+	helmDepBuild := exec.CommandContext(ctx, helmBinary, helmDepBuildArgs...)
+	if err := helmDepBuild.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("failed to build dependencies of chart %v: %w", chartPath, err)
+	}
+	return nil
+}
+
+// `helm dependency build` rewrites the `charts/` directory of a chart,
+// so concurrent builds of the same chart must never overlap
+var chartLocks = struct {
+	sync.Mutex
+	locks map[string]*sync.Mutex
+}{locks: map[string]*sync.Mutex{}}
+
+// returns the process-wide lock for a chart directory
+func chartLock(chartPath string) *sync.Mutex {
+	if absPath, err := filepath.Abs(chartPath); err == nil {
+		chartPath = absPath
+	}
+	chartLocks.Lock()
+	defer chartLocks.Unlock()
+	lock, ok := chartLocks.locks[chartPath]
+	if !ok {
+		lock = &sync.Mutex{}
+		chartLocks.locks[chartPath] = lock
+	}
+	return lock
+}
+
+type dependencyBuild struct {
+	// closed when the build finished
+	done chan struct{}
+	err  error
+}
+
+// builds the dependencies of each chart at most once per test run. Builds run under
+// the context of the test run, not of the test case that happens to request them first
+type dependencyCache struct {
+	ctx    context.Context
+	mu     sync.Mutex
+	skip   bool
+	build  func(ctx context.Context, chartPath string) error
+	builds map[string]*dependencyBuild
+}
+
+// dependencies are built by the renderer of the test run, so the SDK renderer
+// does not need a helm binary
+func newDependencyCache(ctx context.Context, settings TestRunSettings) *dependencyCache {
+	builder, ok := settings.renderer().(DependencyBuilder)
+	if !ok {
+		builder = CLIRenderer{Binary: settings.HelmBinary}
+	}
+	return &dependencyCache{
+		ctx:    ctx,
+		skip:   settings.SkipDependencyBuild,
+		build:  builder.BuildDependencies,
+		builds: map[string]*dependencyBuild{},
+	}
+}
+
+// builds the chart dependencies on the first call for a chart path, subsequent
+// calls wait for and return the result of the first build. Waiting stops when ctx
+// is done. Builds that were cancelled are not remembered and start over on the next call
+func (c *dependencyCache) Build(ctx context.Context, chartPath string) error {
+	if c.skip {
+		return nil
+	}
+	c.mu.Lock()
+	b, ok := c.builds[chartPath]
+	if !ok {
+		b = &dependencyBuild{done: make(chan struct{})}
+		c.builds[chartPath] = b
+		go c.run(chartPath, b)
+	}
+	c.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+		return b.err
+	}
+}
+
+func (c *dependencyCache) run(chartPath string, b *dependencyBuild) {
+	defer close(b.done)
+	lock := chartLock(chartPath)
+	lock.Lock()
+	defer lock.Unlock()
+	b.err = c.build(c.ctx, chartPath)
+	if errors.Is(b.err, context.Canceled) || errors.Is(b.err, context.DeadlineExceeded) {
+		c.mu.Lock()
+		if c.builds[chartPath] == b {
+			delete(c.builds, chartPath)
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,120 @@
+package helmspec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyCacheBuildsEachChartOnce(t *testing.T) {
+	builds := map[string]*int32{"a": new(int32), "b": new(int32)}
+	cache := newDependencyCache(context.Background(), TestRunSettings{})
+	cache.build = func(_ context.Context, chartPath string) error {
+		atomic.AddInt32(builds[chartPath], 1)
+		return nil
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		for chartPath := range builds {
+			wg.Add(1)
+			go func(chartPath string) {
+				defer wg.Done()
+				assert.NoError(t, cache.Build(context.Background(), chartPath))
+			}(chartPath)
+		}
+	}
+	wg.Wait()
+	for chartPath, count := range builds {
+		assert.Equal(t, int32(1), *count, chartPath)
+	}
+}
+
+func TestDependencyCacheRemembersFailures(t *testing.T) {
+	count := 0
+	cache := newDependencyCache(context.Background(), TestRunSettings{})
+	cache.build = func(_ context.Context, chartPath string) error {
+		count++
+		return errors.New("boom")
+	}
+	assert.ErrorContains(t, cache.Build(context.Background(), "a"), "boom")
+	assert.ErrorContains(t, cache.Build(context.Background(), "a"), "boom")
+	assert.Equal(t, 1, count)
+}
+
+func TestDependencyCacheSkipsBuild(t *testing.T) {
+	cache := newDependencyCache(context.Background(), TestRunSettings{SkipDependencyBuild: true})
+	cache.build = func(_ context.Context, chartPath string) error {
+		t.Fatal("dependencies should not be built")
+		return nil
+	}
+	assert.NoError(t, cache.Build(context.Background(), "a"))
+}
+
+func TestBuildDependenciesFailsForMissingChart(t *testing.T) {
+	err := BuildDependencies(context.Background(), DefaultHelmBinary, "./not/an/existing/chart")
+	assert.ErrorContains(t, err, "failed to build dependencies")
+}
+
+func TestSDKRendererBuildsDependenciesWithoutHelmBinary(t *testing.T) {
+	t.Setenv("PATH", "")
+	t.Setenv("HELM_REPOSITORY_CONFIG", filepath.Join(t.TempDir(), "repositories.yaml"))
+	dir := t.TempDir()
+	writeChart := func(path string, chartYAML string) {
+		assert.NoError(t, os.MkdirAll(filepath.Join(path, "templates"), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(path, "Chart.yaml"), []byte(chartYAML), 0o644))
+	}
+	writeChart(filepath.Join(dir, "sub"), "apiVersion: v2\nname: sub\nversion: 0.1.0\n")
+	chartPath := filepath.Join(dir, "parent")
+	writeChart(chartPath, `apiVersion: v2
+name: parent
+version: 0.1.0
+dependencies:
+- name: sub
+  version: 0.1.0
+  repository: file://../sub
+`)
+	cache := newDependencyCache(context.Background(), TestRunSettings{Renderer: SDKRenderer{}, HelmBinary: "./not/an/existing/helm"})
+	assert.NoError(t, cache.Build(context.Background(), chartPath))
+	assert.FileExists(t, filepath.Join(chartPath, "charts", "sub-0.1.0.tgz"))
+	// charts without dependencies are fine as well
+	assert.NoError(t, cache.Build(context.Background(), "./testdata/charts/example"))
+	assert.ErrorContains(t, cache.Build(context.Background(), "./not/an/existing/chart"), "failed to build dependencies")
+}
+
+func TestDependencyCacheWaitsOnlyUntilTheTestCaseIsDone(t *testing.T) {
+	release := make(chan struct{})
+	cache := newDependencyCache(context.Background(), TestRunSettings{})
+	cache.build = func(_ context.Context, chartPath string) error {
+		<-release
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, cache.Build(ctx, "a"), context.DeadlineExceeded)
+	// the build itself is not affected by the timeout of the first test case
+	close(release)
+	assert.NoError(t, cache.Build(context.Background(), "a"))
+}
+
+func TestDependencyCacheDoesNotRememberCancelledBuilds(t *testing.T) {
+	count := 0
+	cache := newDependencyCache(context.Background(), TestRunSettings{})
+	cache.build = func(_ context.Context, chartPath string) error {
+		count++
+		if count == 1 {
+			return fmt.Errorf("failed to build dependencies: %w", context.Canceled)
+		}
+		return nil
+	}
+	assert.ErrorIs(t, cache.Build(context.Background(), "a"), context.Canceled)
+	assert.NoError(t, cache.Build(context.Background(), "a"))
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,35 @@
+package helmspec
+
+import "regexp"
+
+// selects which test cases of a test run are executed, all others are reported as skipped
+type TestCaseFilter struct {
+	// only run test cases whose `<spec title>/<test case title>` matches
+	Run *regexp.Regexp
+	// only run test cases with at least one of these tags
+	Tags []string
+	// do not run test cases with any of these tags
+	ExcludeTags []string
+}
+
+func hasAnyTag(tags []string, wanted []string) bool {
+	for _, tag := range tags {
+		for _, w := range wanted {
+			if tag == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// returns true if the test case of the given spec should be executed
+func (f TestCaseFilter) Matches(specTitle string, t TestCase) bool {
+	if f.Run != nil && !f.Run.MatchString(specTitle+"/"+t.Title) {
+		return false
+	}
+	if len(f.Tags) > 0 && !hasAnyTag(t.Tags, f.Tags) {
+		return false
+	}
+	return !hasAnyTag(t.Tags, f.ExcludeTags)
+}
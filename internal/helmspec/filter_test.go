@@ -0,0 +1,46 @@
+package helmspec
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestCaseFilter(t *testing.T) {
+	type testCase struct {
+		title   string
+		filter  TestCaseFilter
+		matches bool
+	}
+	ingress := TestCase{Title: "ingress enabled", Tags: []string{"ingress", "slow"}}
+	testCases := []testCase{
+		{title: "empty filter", filter: TestCaseFilter{}, matches: true},
+		{title: "matching run", filter: TestCaseFilter{Run: regexp.MustCompile("^example/ingress")}, matches: true},
+		{title: "non-matching run", filter: TestCaseFilter{Run: regexp.MustCompile("^other/")}, matches: false},
+		{title: "matching tag", filter: TestCaseFilter{Tags: []string{"service", "ingress"}}, matches: true},
+		{title: "non-matching tag", filter: TestCaseFilter{Tags: []string{"service"}}, matches: false},
+		{title: "excluded tag", filter: TestCaseFilter{ExcludeTags: []string{"slow"}}, matches: false},
+		{title: "exclude wins", filter: TestCaseFilter{Tags: []string{"ingress"}, ExcludeTags: []string{"slow"}}, matches: false},
+	}
+	for _, c := range testCases {
+		t.Run(c.title, func(t *testing.T) {
+			assert.Equal(t, c.matches, c.filter.Matches("example", ingress))
+		})
+	}
+}
+
+func TestFilteredTestCasesAreSkipped(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
+	assert.NoError(t, err)
+	filter := TestCaseFilter{Run: regexp.MustCompile("/when overwriting the image field$")}
+	result := spec.Execute(context.Background(), TestRunSettings{Filter: filter})
+	assert.True(t, result.Succeeded)
+	assert.Equal(t, len(spec.TestCases), len(result.TestCaseResults))
+	assert.False(t, result.TestCaseResults[0].Skipped)
+	for _, r := range result.TestCaseResults[1:] {
+		assert.True(t, r.Skipped)
+		assert.Empty(t, r.Manifest)
+	}
+}
@@ -0,0 +1,128 @@
+// gendocs writes the doc comments of the spec file types to spec_docs.go,
+// so the json schema of spec files can describe every field
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// the types a spec file is made of
+var specTypes = []string{"HelmSpec", "TestCase", "RenderInstructions", "Assertion", "DocumentSelector"}
+
+// returns a doc comment as a single string without the comment markers
+func docText(group *ast.CommentGroup) string {
+	return strings.TrimSpace(group.Text())
+}
+
+// collects the doc comments of the spec types and their fields, keyed by
+// type and field name. The doc comment of the type itself has an empty field name
+func collectDocs(dir string) (docs map[string]map[string]string, err error) {
+	wanted := map[string]bool{}
+	for _, name := range specTypes {
+		wanted[name] = true
+	}
+	fset := token.NewFileSet()
+	packages, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	docs = map[string]map[string]string{}
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, s := range gen.Specs {
+					spec := s.(*ast.TypeSpec)
+					structType, ok := spec.Type.(*ast.StructType)
+					if !ok || !wanted[spec.Name.Name] {
+						continue
+					}
+					fields := map[string]string{}
+					if gen.Doc != nil {
+						fields[""] = docText(gen.Doc)
+					}
+					for _, field := range structType.Fields.List {
+						if field.Doc == nil {
+							continue
+						}
+						for _, name := range field.Names {
+							if !name.IsExported() {
+								continue
+							}
+							fields[name.Name] = docText(field.Doc)
+						}
+					}
+					docs[spec.Name.Name] = fields
+				}
+			}
+		}
+	}
+	for _, name := range specTypes {
+		if _, ok := docs[name]; !ok {
+			return nil, fmt.Errorf("type `%v` not found in %v", name, dir)
+		}
+	}
+	return docs, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renders the docs as go source
+func render(docs map[string]map[string]string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, "// Code generated by gendocs; DO NOT EDIT.")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "package helmspec")
+	fmt.Fprintln(buf)
+	fmt.Fprintln(buf, "// doc comments of the spec file types, keyed by type and field name.")
+	fmt.Fprintln(buf, "// The doc comment of the type itself has an empty field name")
+	fmt.Fprintln(buf, "var specDocs = map[string]map[string]string{")
+	for _, name := range specTypes {
+		fmt.Fprintf(buf, "%q: {\n", name)
+		for _, field := range sortedKeys(docs[name]) {
+			fmt.Fprintf(buf, "%q: %q,\n", field, docs[name][field])
+		}
+		fmt.Fprintln(buf, "},")
+	}
+	fmt.Fprintln(buf, "}")
+	return format.Source(buf.Bytes())
+}
+
+func main() {
+	dir := flag.String("dir", ".", "directory of the helmspec package")
+	out := flag.String("out", "spec_docs.go", "file to write the docs to")
+	flag.Parse()
+	docs, err := collectDocs(*dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	source, err := render(docs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = os.WriteFile(*out, source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
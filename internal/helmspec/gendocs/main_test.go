@@ -0,0 +1,18 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpecDocsAreUpToDate(t *testing.T) {
+	docs, err := collectDocs("..")
+	assert.NoError(t, err)
+	generated, err := render(docs)
+	assert.NoError(t, err)
+	current, err := os.ReadFile("../spec_docs.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(generated), string(current), "spec_docs.go is outdated, run `go generate ./internal/helmspec`")
+}
@@ -0,0 +1,166 @@
+package helmspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// the kubernetes version of the API types helm-spec was built with,
+// the type schemas are generated from these types
+const TypeSchemaKubeVersion = "1.25.2"
+
+var jsonMarshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// schemas for types with custom json serialization, keyed by package path and name
+var customTypeSchemas = map[string]map[string]interface{}{
+	"k8s.io/apimachinery/pkg/api/resource.Quantity": {
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "number"},
+		},
+	},
+	"k8s.io/apimachinery/pkg/util/intstr.IntOrString": {
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "integer"},
+		},
+	},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Time":      {"type": []interface{}{"string", "null"}},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.MicroTime": {"type": []interface{}{"string", "null"}},
+	"k8s.io/apimachinery/pkg/apis/meta/v1.Duration":  {"type": "string"},
+}
+
+// generates json schemas from go types. Unlike the `standalone-strict` schemas of the
+// kubernetes-json-schema project they are not derived from the OpenAPI spec, so they
+// only reject unknown fields and values of the wrong type. Required fields, enums,
+// formats and other constraints of the API server are not checked
+type kubeSchemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+func typeID(t reflect.Type) string {
+	return t.PkgPath() + "." + t.Name()
+}
+
+// allows `null` in addition to the given json type, like the api server does for unset fields
+func nullable(jsonType string) map[string]interface{} {
+	return map[string]interface{}{"type": []interface{}{jsonType, "null"}}
+}
+
+func (g *kubeSchemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		return g.schemaFor(t.Elem())
+	}
+	if s, ok := customTypeSchemas[typeID(t)]; ok {
+		return s
+	}
+	if t.Kind() != reflect.Struct && (t.Implements(jsonMarshaler) || reflect.PtrTo(t).Implements(jsonMarshaler)) {
+		return map[string]interface{}{}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return nullable("boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return nullable("integer")
+	case reflect.Float32, reflect.Float64:
+		return nullable("number")
+	case reflect.String:
+		return nullable("string")
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// byte slices are base64 encoded strings
+			return nullable("string")
+		}
+		s := nullable("array")
+		s["items"] = g.schemaFor(t.Elem())
+		return s
+	case reflect.Map:
+		s := nullable("object")
+		s["additionalProperties"] = g.schemaFor(t.Elem())
+		return s
+	case reflect.Struct:
+		if t.Implements(jsonMarshaler) || reflect.PtrTo(t).Implements(jsonMarshaler) {
+			// i.e. runtime.RawExtension which can hold arbitrary objects
+			return map[string]interface{}{}
+		}
+		// slashes would have to be escaped in the json pointer of the reference
+		id := strings.ReplaceAll(typeID(t), "/", ".")
+		if _, ok := g.definitions[id]; !ok {
+			// register before recursing to support self-referencing types
+			g.definitions[id] = nil
+			properties := map[string]interface{}{}
+			g.addProperties(t, properties)
+			s := nullable("object")
+			s["properties"] = properties
+			s["additionalProperties"] = false
+			g.definitions[id] = s
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + id}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// adds the json properties of a struct, including those of inlined structs
+func (g *kubeSchemaGenerator) addProperties(t reflect.Type, properties map[string]interface{}) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && (name == "" || strings.Contains(options, "inline")) {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			g.addProperties(fieldType, properties)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = g.schemaFor(field.Type)
+	}
+}
+
+// returns the schema file name for a kind in the kubernetes-json-schema layout,
+// i.e. `deployment-apps-v1.json` or `service-v1.json`
+func schemaFileName(gvk schema.GroupVersionKind) string {
+	parts := []string{strings.ToLower(gvk.Kind)}
+	if gvk.Group != "" {
+		group, _, _ := strings.Cut(gvk.Group, ".")
+		parts = append(parts, group)
+	}
+	parts = append(parts, gvk.Version)
+	return strings.Join(parts, "-") + ".json"
+}
+
+// generates type schemas for all built-in kinds of TypeSchemaKubeVersion
+func typeSchemas() (schemas map[string][]byte, err error) {
+	schemas = map[string][]byte{}
+	for gvk, t := range scheme.Scheme.AllKnownTypes() {
+		if gvk.Version == "__internal" {
+			continue
+		}
+		g := kubeSchemaGenerator{definitions: map[string]interface{}{}}
+		root := g.schemaFor(t)
+		root["definitions"] = g.definitions
+		content, err := json.Marshal(root)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate schema for %v: %w", gvk, err)
+		}
+		schemas[schemaFileName(gvk)] = content
+	}
+	return schemas, nil
+}
@@ -1,32 +1,124 @@
 package helmspec
 
+import (
+	"context"
+	"time"
+)
+
 type TestSuiteResult struct {
 	Succeeded   bool         `json:"succeeded"`
 	SpecResults []SpecResult `json:"specResults"`
+	// template coverage per chart, only computed if requested
+	Coverage []TemplateCoverage `json:"coverage,omitempty"`
+}
+
+type TestRunSettings struct {
+	// maximum number of test cases to execute concurrently,
+	// defaults to the number of CPUs if not positive
+	Jobs int
+	// do not run `helm dependency build`, i.e. for charts with vendored dependencies
+	SkipDependencyBuild bool
+	// renders the charts, defaults to the CLI renderer if nil
+	Renderer Renderer
+	// overwrite stored snapshots with the rendered manifests
+	UpdateSnapshots bool
+	// validates rendered manifests against kubernetes schemas, disabled if nil
+	SchemaValidator *SchemaValidator
+	// selects the test cases to run, all others are skipped
+	Filter TestCaseFilter
+	// maximum duration of a test case including building chart dependencies,
+	// unlimited if not positive. Specs can override it with their `timeout`
+	Timeout time.Duration
+	// helm binary for rendering and building chart dependencies, defaults to `helm` on the PATH
+	HelmBinary string
+	// compute which chart templates were rendered by the test cases
+	Coverage bool
+}
+
+// returns the configured renderer or the default CLI renderer
+func (s TestRunSettings) renderer() Renderer {
+	if s.Renderer == nil {
+		return CLIRenderer{Binary: s.HelmBinary}
+	}
+	return s.Renderer
+}
+
+// returns the configured helm binary or the default one
+func (s TestRunSettings) helmBinary() string {
+	if s.HelmBinary == "" {
+		return DefaultHelmBinary
+	}
+	return s.HelmBinary
 }
 
 type TestRunner interface {
-	Run(specFiles []string) (TestSuiteResult, error)
+	Run(ctx context.Context, specFiles []string, settings TestRunSettings) (TestSuiteResult, error)
 }
 
 type HelmTestRunner struct{}
 
-func (runner HelmTestRunner) Run(specFiles []string) (result TestSuiteResult, err error) {
-	specs := []*HelmSpec{}
+// validates and loads spec files, returns an InvalidSpecsError and no specs
+// if any spec file has problems
+func LoadSpecs(specFiles []string) (specs []*HelmSpec, err error) {
+	problems := []SpecProblem{}
 	for _, f := range specFiles {
-		spec, err := NewSpec(f)
+		spec, p, err := loadSpecFile(f)
 		if err != nil {
-			return result, err
+			return nil, err
 		}
+		problems = append(problems, p...)
 		specs = append(specs, spec)
 	}
+	if len(problems) > 0 {
+		return nil, &InvalidSpecsError{Problems: problems}
+	}
+	return specs, nil
+}
+
+// identifies a single test case across all specs of a test run
+type testCaseRef struct {
+	spec     int
+	testCase int
+}
+
+// runs all test cases of the spec files. Spec files are validated first, nothing runs
+// if any has problems. If ctx is cancelled, running helm processes are killed, test
+// cases that did not start yet are skipped and the partial result is returned
+// together with the context error
+func (runner HelmTestRunner) Run(ctx context.Context, specFiles []string, settings TestRunSettings) (result TestSuiteResult, err error) {
+	specs, err := LoadSpecs(specFiles)
+	if err != nil {
+		return result, err
+	}
+	// test cases of all specs share one worker pool, results are
+	// stored by index so the report order does not depend on scheduling
+	refs := []testCaseRef{}
+	testCaseResults := make([][]TestCaseResult, len(specs))
+	for s, spec := range specs {
+		testCaseResults[s] = make([]TestCaseResult, len(spec.TestCases))
+		for c := range spec.TestCases {
+			refs = append(refs, testCaseRef{spec: s, testCase: c})
+		}
+	}
+	dependencies := newDependencyCache(ctx, settings)
+	parallelize(len(refs), settings.Jobs, func(idx int) {
+		ref := refs[idx]
+		testCaseResults[ref.spec][ref.testCase] = specs[ref.spec].executeTestCase(ctx, ref.testCase, settings, dependencies)
+	})
 	result = TestSuiteResult{
 		Succeeded: true,
 	}
-	for _, spec := range specs {
-		r := spec.Execute()
+	for s, spec := range specs {
+		r := spec.collectResults(testCaseResults[s])
 		result.Succeeded = result.Succeeded && r.Succeeded
 		result.SpecResults = append(result.SpecResults, r)
 	}
+	if err = ctx.Err(); err != nil {
+		result.Succeeded = false
+		return result, err
+	}
+	if settings.Coverage {
+		result.Coverage, err = ComputeTemplateCoverage(result)
+	}
 	return result, err
 }
@@ -1,6 +1,7 @@
 package helmspec
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -8,13 +9,42 @@ import (
 
 func TestHelmTestRunner(t *testing.T) {
 	specFiles := []string{"./testdata/charts/example/specs/example_spec.yaml", "./testdata/charts/example/specs/successful_spec.yaml"}
-	result, err := HelmTestRunner{}.Run(specFiles)
+	result, err := HelmTestRunner{}.Run(context.Background(), specFiles, TestRunSettings{})
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
 }
 
 func TestHelmTestRunnerAbortsIfItFailsToLoadAnySpec(t *testing.T) {
 	specFiles := []string{"./testdata/charts/example/specs/example_spec.yaml", "./testdata/charts/example/specs/does_not_exist.yaml"}
-	_, err := HelmTestRunner{}.Run(specFiles)
+	_, err := HelmTestRunner{}.Run(context.Background(), specFiles, TestRunSettings{})
 	assert.Error(t, err)
 }
+
+func TestHelmTestRunnerPreservesOrderWithConcurrentJobs(t *testing.T) {
+	specFiles := []string{"./testdata/charts/example/specs/example_spec.yaml", "./testdata/charts/example/specs/successful_spec.yaml"}
+	result, err := HelmTestRunner{}.Run(context.Background(), specFiles, TestRunSettings{Jobs: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(result.SpecResults))
+	for idx, f := range specFiles {
+		spec, err := NewSpec(f)
+		assert.NoError(t, err)
+		assert.Equal(t, len(spec.TestCases), len(result.SpecResults[idx].TestCaseResults))
+		for c, testCase := range spec.TestCases {
+			assert.Equal(t, testCase.Title, result.SpecResults[idx].TestCaseResults[c].Title)
+		}
+	}
+	assert.False(t, result.Succeeded)
+}
+
+func TestHelmTestRunnerReportsPartialResultsWhenCancelled(t *testing.T) {
+	specFiles := []string{"./testdata/charts/example/specs/successful_spec.yaml"}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := HelmTestRunner{}.Run(ctx, specFiles, TestRunSettings{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, result.Succeeded)
+	assert.Equal(t, 1, len(result.SpecResults))
+	for _, r := range result.SpecResults[0].TestCaseResults {
+		assert.True(t, r.Skipped)
+	}
+}
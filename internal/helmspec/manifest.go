@@ -0,0 +1,44 @@
+package helmspec
+
+import (
+	"regexp"
+	"strings"
+)
+
+// a single yaml document of a rendered manifest
+type Document struct {
+	// the chart template the document was rendered from, i.e. `example/templates/service.yaml`
+	Source string `json:"source"`
+	// the yaml content of the document
+	Content string `json:"content"`
+}
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+var sourceComment = regexp.MustCompile(`(?m)^# Source: (.+)$`)
+
+// splits a rendered manifest into its yaml documents, skipping empty ones
+func SplitManifest(manifest string) (documents []Document) {
+	for _, content := range documentSeparator.Split(manifest, -1) {
+		if isBlankDocument(content) {
+			continue
+		}
+		document := Document{Content: strings.TrimLeft(content, "\n")}
+		if match := sourceComment.FindStringSubmatch(content); match != nil {
+			document.Source = strings.TrimSpace(match[1])
+		}
+		documents = append(documents, document)
+	}
+	return documents
+}
+
+// returns true if a document consists only of whitespace and comments
+func isBlankDocument(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package helmspec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitManifest(t *testing.T) {
+	manifest := `---
+# Source: example/templates/service.yaml
+apiVersion: v1
+kind: Service
+---
+# Source: example/templates/empty.yaml
+---
+# Source: example/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+`
+	documents := SplitManifest(manifest)
+	assert.Equal(t, 2, len(documents))
+	assert.Equal(t, "example/templates/service.yaml", documents[0].Source)
+	assert.Contains(t, documents[0].Content, "kind: Service")
+	assert.Equal(t, "example/templates/deployment.yaml", documents[1].Source)
+	assert.Contains(t, documents[1].Content, "kind: Deployment")
+}
@@ -0,0 +1,307 @@
+package helmspec
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// whole numbers are parsed as floats, encode them as integers like yq prints them
+func normalizeParameter(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return int64(v)
+		}
+	case map[string]interface{}:
+		normalized := map[string]interface{}{}
+		for key, item := range v {
+			normalized[key] = normalizeParameter(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for idx, item := range v {
+			normalized[idx] = normalizeParameter(item)
+		}
+		return normalized
+	}
+	return value
+}
+
+// encodes a matrix parameter value as a yaml node
+func parameterNode(value interface{}) (*yamlv3.Node, error) {
+	node := &yamlv3.Node{}
+	err := node.Encode(normalizeParameter(value))
+	return node, err
+}
+
+// switches maps and lists to flow style so they fit on a single line
+func flowStyle(node *yamlv3.Node) {
+	if node.Kind == yamlv3.MappingNode || node.Kind == yamlv3.SequenceNode {
+		node.Style = yamlv3.FlowStyle
+	}
+	for _, child := range node.Content {
+		flowStyle(child)
+	}
+}
+
+// formats a matrix parameter value for titles, queries and command line arguments.
+// Strings are used as they are, other values are formatted as single line yaml,
+// i.e. `1.5`, `null` or `{port: 80}`
+func formatParameter(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return formatYAMLParameter(value)
+}
+
+// formats a matrix parameter value as single line yaml, strings are quoted if
+// they would be parsed as another type, i.e. `"yes"` or `"1.0"`
+func formatYAMLParameter(value interface{}) string {
+	node, err := parameterNode(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	flowStyle(node)
+	out, err := yamlv3.Marshal(node)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// returns every combination of matrix parameters, ordered by parameter name
+func matrixCombinations(matrix map[string][]interface{}) []map[string]interface{} {
+	names := []string{}
+	for name := range matrix {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	combinations := []map[string]interface{}{{}}
+	for _, name := range names {
+		expanded := []map[string]interface{}{}
+		for _, combination := range combinations {
+			for _, value := range matrix[name] {
+				c := map[string]interface{}{name: value}
+				for k, v := range combination {
+					c[k] = v
+				}
+				expanded = append(expanded, c)
+			}
+		}
+		combinations = expanded
+	}
+	return combinations
+}
+
+// replaces `${name}` placeholders for all parameters, other placeholders are left untouched
+func substituteParameters(text string, parameters map[string]string) string {
+	for name, value := range parameters {
+		text = strings.ReplaceAll(text, "${"+name+"}", value)
+	}
+	return text
+}
+
+// returns true if the text contains a placeholder for any of the parameters
+func containsPlaceholder(text string, parameters map[string]string) bool {
+	for name := range parameters {
+		if strings.Contains(text, "${"+name+"}") {
+			return true
+		}
+	}
+	return false
+}
+
+// replaces placeholders in a yaml node tree. An unquoted placeholder making up a whole
+// scalar is replaced with the parameter value as yaml, so strings like `"yes"` stay
+// strings and maps stay maps. Placeholders inside longer scalars are replaced as text
+func substituteNode(node *yamlv3.Node, values map[string]interface{}, parameters map[string]string) error {
+	if node.Kind != yamlv3.ScalarNode {
+		for _, child := range node.Content {
+			if err := substituteNode(child, values, parameters); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if node.Style == 0 && strings.HasPrefix(node.Value, "${") && strings.HasSuffix(node.Value, "}") {
+		if value, ok := values[strings.TrimSuffix(strings.TrimPrefix(node.Value, "${"), "}")]; ok {
+			replacement, err := parameterNode(value)
+			if err != nil {
+				return err
+			}
+			replacement.HeadComment, replacement.LineComment, replacement.FootComment = node.HeadComment, node.LineComment, node.FootComment
+			*node = *replacement
+			return nil
+		}
+	}
+	substituted := substituteParameters(node.Value, parameters)
+	if substituted != node.Value && node.Style == 0 {
+		// resolve the type of the substituted value like a plain scalar in the spec file
+		node.Tag = ""
+	}
+	node.Value = substituted
+	return nil
+}
+
+// replaces `${name}` placeholders in a yaml document, see substituteNode. Documents
+// without placeholders are returned as they are. Documents that are not valid yaml
+// before the substitution, i.e. because of placeholders in flow maps, are substituted
+// as text with the values formatted as yaml
+func substituteYAMLParameters(document string, values map[string]interface{}, parameters map[string]string) (string, error) {
+	if !containsPlaceholder(document, parameters) {
+		return document, nil
+	}
+	root := yamlv3.Node{}
+	if err := yamlv3.Unmarshal([]byte(document), &root); err != nil {
+		formatted := map[string]string{}
+		for name, value := range values {
+			formatted[name] = formatYAMLParameter(value)
+		}
+		return substituteParameters(document, formatted), nil
+	}
+	if err := substituteNode(&root, values, parameters); err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	encoder := yamlv3.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// describes a parameter set, i.e. `[ingressClass=nginx, serviceType=NodePort]`
+func FormatParameters(parameters map[string]string) string {
+	pairs := []string{}
+	for name, value := range parameters {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, ", ") + "]"
+}
+
+// the matrix parameter spec-level `kubeVersions` are added as
+const kubeVersionParameter = "kubeVersion"
+
+// adds the kube versions of a spec to the matrix of a test case, unless the test case
+// has a kube version after merging the defaults or already has a `kubeVersion` matrix parameter
+func (t TestCase) withKubeVersions(kubeVersions []string) TestCase {
+	if len(kubeVersions) == 0 || t.Render.KubeVersion != "" {
+		return t
+	}
+	if _, ok := t.Matrix[kubeVersionParameter]; ok {
+		return t
+	}
+	matrix := map[string][]interface{}{}
+	for name, values := range t.Matrix {
+		matrix[name] = values
+	}
+	for _, v := range kubeVersions {
+		matrix[kubeVersionParameter] = append(matrix[kubeVersionParameter], v)
+	}
+	t.Matrix = matrix
+	t.Render.KubeVersion = "${" + kubeVersionParameter + "}"
+	return t
+}
+
+// expands a test case with a matrix into one test case per parameter combination.
+// Test cases without a matrix are returned as they are
+func (t TestCase) expandMatrix() (testCases []TestCase, err error) {
+	if len(t.Matrix) == 0 {
+		return []TestCase{t}, nil
+	}
+	for name, values := range t.Matrix {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("matrix parameter `%v` has no values", name)
+		}
+	}
+	for _, values := range matrixCombinations(t.Matrix) {
+		parameters := map[string]string{}
+		for name, value := range values {
+			parameters[name] = formatParameter(value)
+		}
+		c := t
+		c.Matrix = nil
+		c.Parameters = parameters
+		c.Title = substituteParameters(t.Title, parameters)
+		if c.Title == t.Title {
+			c.Title = strings.TrimSpace(c.Title + " " + FormatParameters(parameters))
+		}
+		if c.Render, err = t.Render.substituteParameters(values, parameters); err != nil {
+			return nil, err
+		}
+		c.Assertions = []Assertion{}
+		for _, a := range t.Assertions {
+			if a, err = a.substituteParameters(values, parameters); err != nil {
+				return nil, err
+			}
+			c.Assertions = append(c.Assertions, a)
+		}
+		testCases = append(testCases, c)
+	}
+	return testCases, nil
+}
+
+// replaces `${name}` placeholders of matrix parameters in the render instructions,
+// the values documents are substituted as yaml
+func (r RenderInstructions) substituteParameters(values map[string]interface{}, parameters map[string]string) (_ RenderInstructions, err error) {
+	r.ReleaseName = substituteParameters(r.ReleaseName, parameters)
+	r.Namespace = substituteParameters(r.Namespace, parameters)
+	r.ValuesFiles = substituteAll(r.ValuesFiles, parameters)
+	if r.Values, err = substituteYAMLParameters(r.Values, values, parameters); err != nil {
+		return r, fmt.Errorf("invalid values: %w", err)
+	}
+	if r.DefaultValues, err = substituteYAMLParameters(r.DefaultValues, values, parameters); err != nil {
+		return r, fmt.Errorf("invalid default values: %w", err)
+	}
+	r.KubeVersion = substituteParameters(r.KubeVersion, parameters)
+	r.APIVersions = substituteAll(r.APIVersions, parameters)
+	r.ExtraArgs = substituteAll(r.ExtraArgs, parameters)
+	r.ExpectedError = substituteParameters(r.ExpectedError, parameters)
+	return r, nil
+}
+
+// replaces `${name}` placeholders of matrix parameters in the assertion,
+// structured expected results are substituted as yaml
+func (a Assertion) substituteParameters(values map[string]interface{}, parameters map[string]string) (_ Assertion, err error) {
+	a.Description = substituteParameters(a.Description, parameters)
+	a.Query = substituteParameters(a.Query, parameters)
+	if a.Document != nil {
+		a.Document = a.Document.substituteParameters(parameters)
+	}
+	if !a.Structured {
+		a.ExpectedResult = substituteParameters(a.ExpectedResult, parameters)
+		return a, nil
+	}
+	if !containsPlaceholder(a.ExpectedResult, parameters) {
+		return a, nil
+	}
+	expected, err := substituteYAMLParameters(a.ExpectedResult, values, parameters)
+	if err != nil {
+		return a, fmt.Errorf("invalid expected result: %w", err)
+	}
+	a.ExpectedResult, err = canonicalYAML(expected)
+	return a, err
+}
+
+// replaces `${name}` placeholders in a copy of a list of texts
+func substituteAll(texts []string, parameters map[string]string) []string {
+	if texts == nil {
+		return nil
+	}
+	substituted := []string{}
+	for _, text := range texts {
+		substituted = append(substituted, substituteParameters(text, parameters))
+	}
+	return substituted
+}
@@ -0,0 +1,154 @@
+package helmspec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExpandMatrix(t *testing.T) {
+	spec := `
+title: replicas
+matrix:
+  replicas: [1, 2]
+  type: [ClusterIP, NodePort]
+render:
+  values: |
+    replicaCount: ${replicas}
+    service:
+      type: ${type}
+    unrelated: ${HOME}
+  extraArgs: ["--set", "service.port=80${replicas}"]
+assertions:
+- description: service should be ${type}
+  query: .spec.type
+  expectedResult: ${type}
+`
+	testCase := TestCase{}
+	assert.NoError(t, yaml.Unmarshal([]byte(spec), &testCase))
+	testCases, err := testCase.expandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(testCases))
+	expectedTitles := []string{
+		"replicas [replicas=1, type=ClusterIP]",
+		"replicas [replicas=1, type=NodePort]",
+		"replicas [replicas=2, type=ClusterIP]",
+		"replicas [replicas=2, type=NodePort]",
+	}
+	for idx, c := range testCases {
+		assert.Equal(t, expectedTitles[idx], c.Title)
+		assert.Nil(t, c.Matrix)
+		assert.Equal(t, 2, len(c.Parameters))
+	}
+	last := testCases[3]
+	assert.Equal(t, map[string]string{"replicas": "2", "type": "NodePort"}, last.Parameters)
+	assert.Equal(t, "replicaCount: 2\nservice:\n  type: NodePort\nunrelated: ${HOME}\n", last.Render.Values)
+	assert.Equal(t, []string{"--set", "service.port=802"}, last.Render.ExtraArgs)
+	assert.Equal(t, "service should be NodePort", last.Assertions[0].Description)
+	assert.Equal(t, "NodePort", last.Assertions[0].ExpectedResult)
+}
+
+func TestExpandMatrixUsesPlaceholdersInTitle(t *testing.T) {
+	testCase := TestCase{
+		Title:  "service of type ${type}",
+		Matrix: map[string][]interface{}{"type": {"ClusterIP"}},
+	}
+	testCases, err := testCase.expandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, "service of type ClusterIP", testCases[0].Title)
+}
+
+func TestExpandMatrixRejectsEmptyParameters(t *testing.T) {
+	testCase := TestCase{Matrix: map[string][]interface{}{"type": {}}}
+	_, err := testCase.expandMatrix()
+	assert.ErrorContains(t, err, "has no values")
+}
+
+func TestMatrixTestCasesAreReportedSeparately(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/matrix_spec.yaml")
+	assert.NoError(t, err)
+	result := spec.Execute(context.Background(), TestRunSettings{})
+	assert.True(t, result.Succeeded)
+	parameters := []string{}
+	for _, r := range result.TestCaseResults {
+		if r.Parameters != nil {
+			parameters = append(parameters, r.Parameters["type"])
+		}
+	}
+	assert.Equal(t, []string{"ClusterIP", "NodePort", "LoadBalancer"}, parameters)
+}
+
+func TestExpandMatrixSubstitutesDocumentSelectors(t *testing.T) {
+	testCase := TestCase{
+		Matrix: map[string][]interface{}{"component": {"web"}},
+		Assertions: []Assertion{{
+			Document: &DocumentSelector{Name: "foo-${component}", Labels: map[string]string{"component": "${component}"}},
+		}},
+	}
+	testCases, err := testCase.expandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, &DocumentSelector{Name: "foo-web", Labels: map[string]string{"component": "web"}}, testCases[0].Assertions[0].Document)
+	assert.Equal(t, "foo-${component}", testCase.Assertions[0].Document.Name)
+}
+
+func TestExpandMatrixSubstitutesValuesAsYAML(t *testing.T) {
+	testCase := TestCase{
+		Title: "${flag} ${version} ${resources}",
+		Matrix: map[string][]interface{}{
+			"flag":      {"yes"},
+			"version":   {"1.0"},
+			"label":     {"a: b"},
+			"resources": {map[string]interface{}{"cpu": "100m", "replicas": float64(2)}},
+		},
+		Render: RenderInstructions{
+			Values: "flag: ${flag}\nversion: ${version}\nlabel: ${label}\nresources: ${resources}\nimage: \"repo:${version}\"\nport: 80${version}\n",
+		},
+	}
+	testCases, err := testCase.expandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(testCases))
+	assert.Equal(t, "yes 1.0 {cpu: 100m, replicas: 2}", testCases[0].Title)
+	values := map[string]interface{}{}
+	assert.NoError(t, yaml.Unmarshal([]byte(testCases[0].Render.Values), &values))
+	assert.Equal(t, map[string]interface{}{
+		"flag":      "yes",
+		"version":   "1.0",
+		"label":     "a: b",
+		"resources": map[string]interface{}{"cpu": "100m", "replicas": float64(2)},
+		"image":     "repo:1.0",
+		"port":      float64(801),
+	}, values)
+}
+
+func TestExpandMatrixSubstitutesDefaultsAndQueries(t *testing.T) {
+	testCase := TestCase{
+		Matrix: map[string][]interface{}{"type": {"NodePort"}},
+		Assertions: []Assertion{{
+			Query:          `.spec.ports[] | select(.name == "${type}") | .port`,
+			ExpectedResult: "type: ${type}",
+			Structured:     true,
+		}},
+	}
+	testCase.Render = testCase.Render.withDefaults(RenderInstructions{
+		ReleaseName: "foo-${type}",
+		Values:      "service:\n  type: ${type}\n",
+	})
+	testCases, err := testCase.expandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, "foo-NodePort", testCases[0].Render.ReleaseName)
+	assert.Equal(t, "service:\n  type: NodePort\n", testCases[0].Render.DefaultValues)
+	assert.Equal(t, `.spec.ports[] | select(.name == "NodePort") | .port`, testCases[0].Assertions[0].Query)
+	assert.Equal(t, "type: NodePort", testCases[0].Assertions[0].ExpectedResult)
+}
+
+func TestExpandMatrixSubstitutesFlowMapsAsText(t *testing.T) {
+	testCase := TestCase{
+		Matrix: map[string][]interface{}{"flag": {"yes"}, "port": {float64(80)}},
+		Render: RenderInstructions{Values: "service: {enabled: ${flag}, port: ${port}}\n"},
+	}
+	testCases, err := testCase.expandMatrix()
+	assert.NoError(t, err)
+	assert.Equal(t, "service: {enabled: \"yes\", port: 80}\n", testCases[0].Render.Values)
+}
@@ -0,0 +1,134 @@
+package helmspec
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// the query output equals the expected result
+	OperatorEquals = "equals"
+	// the query output does not equal the expected result
+	OperatorNotEquals = "notEquals"
+	// the query output contains the expected result as a substring
+	OperatorContains = "contains"
+	// the query output does not contain the expected result as a substring
+	OperatorNotContains = "notContains"
+	// the query output matches the expected result as a regular expression
+	OperatorMatches = "matches"
+	// the query output is empty, `null` or an empty string, list or map
+	OperatorIsEmpty = "isEmpty"
+	// the query output is `null` or the query did not produce any output
+	OperatorIsNull = "isNull"
+	// the query produced output other than `null`, i.e. an empty string
+	OperatorExists = "exists"
+	// the query output and the expected result are numbers and output > expected
+	OperatorGreaterThan = "greaterThan"
+	// the query output and the expected result are numbers and output >= expected
+	OperatorGreaterThanOrEqual = "greaterThanOrEqual"
+	// the query output and the expected result are numbers and output < expected
+	OperatorLessThan = "lessThan"
+	// the query output and the expected result are numbers and output <= expected
+	OperatorLessThanOrEqual = "lessThanOrEqual"
+)
+
+var AllowedOperators = [...]string{
+	OperatorEquals,
+	OperatorNotEquals,
+	OperatorContains,
+	OperatorNotContains,
+	OperatorMatches,
+	OperatorIsEmpty,
+	OperatorIsNull,
+	OperatorExists,
+	OperatorGreaterThan,
+	OperatorGreaterThanOrEqual,
+	OperatorLessThan,
+	OperatorLessThanOrEqual,
+}
+
+// operators that only look at the query output and ignore the expected result
+var unaryOperators = map[string]bool{
+	OperatorIsEmpty: true,
+	OperatorIsNull:  true,
+	OperatorExists:  true,
+}
+
+// returns true if the operator ignores the expected result
+func IsUnaryOperator(operator string) bool {
+	return unaryOperators[operator]
+}
+
+// takes the untrimmed query output, an empty string value is printed as an empty
+// line by yq while a query without any output prints nothing
+func isNull(output string) bool {
+	actual := strings.TrimSpace(output)
+	return output == "" || actual == "null" || actual == "~"
+}
+
+func isEmpty(output string) bool {
+	switch strings.TrimSpace(output) {
+	case "", "[]", "{}", `""`, "''":
+		return true
+	}
+	return isNull(output)
+}
+
+// parses both sides of a numeric comparison
+func parseNumbers(actual string, expected string) (a float64, e float64, err error) {
+	a, err = strconv.ParseFloat(actual, 64)
+	if err != nil {
+		return a, e, fmt.Errorf("query output `%v` is not a number", actual)
+	}
+	e, err = strconv.ParseFloat(expected, 64)
+	if err != nil {
+		return a, e, fmt.Errorf("expected result `%v` is not a number", expected)
+	}
+	return a, e, nil
+}
+
+// compares the untrimmed output of a query with the expected result using the given operator
+func compare(operator string, output string, expected string) (bool, error) {
+	actual := strings.TrimSpace(output)
+	switch operator {
+	case OperatorEquals, "":
+		return actual == expected, nil
+	case OperatorNotEquals:
+		return actual != expected, nil
+	case OperatorContains:
+		return strings.Contains(actual, expected), nil
+	case OperatorNotContains:
+		return !strings.Contains(actual, expected), nil
+	case OperatorMatches:
+		re, err := regexp.Compile(expected)
+		if err != nil {
+			return false, fmt.Errorf("invalid regular expression `%v`: %w", expected, err)
+		}
+		return re.MatchString(actual), nil
+	case OperatorIsEmpty:
+		return isEmpty(output), nil
+	case OperatorIsNull:
+		return isNull(output), nil
+	case OperatorExists:
+		return !isNull(output), nil
+	case OperatorGreaterThan, OperatorGreaterThanOrEqual, OperatorLessThan, OperatorLessThanOrEqual:
+		a, e, err := parseNumbers(actual, expected)
+		if err != nil {
+			return false, err
+		}
+		switch operator {
+		case OperatorGreaterThan:
+			return a > e, nil
+		case OperatorGreaterThanOrEqual:
+			return a >= e, nil
+		case OperatorLessThan:
+			return a < e, nil
+		default:
+			return a <= e, nil
+		}
+	default:
+		return false, fmt.Errorf("unknown operator `%v`, must be one of `%v`", operator, AllowedOperators)
+	}
+}
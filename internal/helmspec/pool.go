@@ -0,0 +1,40 @@
+package helmspec
+
+import (
+	"runtime"
+	"sync"
+)
+
+// returns the number of workers to use for a given `jobs` setting,
+// falling back to the number of CPUs if it is not a positive number
+func workerCount(jobs int) int {
+	if jobs < 1 {
+		return runtime.NumCPU()
+	}
+	return jobs
+}
+
+// calls fn for every index in [0, n) using at most `jobs` concurrent workers
+// and blocks until all calls have returned
+func parallelize(n int, jobs int, fn func(idx int)) {
+	workers := workerCount(jobs)
+	if workers > n {
+		workers = n
+	}
+	indices := make(chan int)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indices {
+				fn(idx)
+			}
+		}()
+	}
+	for idx := 0; idx < n; idx++ {
+		indices <- idx
+	}
+	close(indices)
+	wg.Wait()
+}
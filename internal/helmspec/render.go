@@ -1,8 +1,16 @@
 package helmspec
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
 )
 
 // inputs for rendering a the chart with `helm template`
@@ -11,24 +19,247 @@ type RenderInstructions struct {
 	ReleaseName string `json:"releaseName"`
 	// the release namespace to pass to `helm template`
 	Namespace string `json:"namespace"`
+	// values files (absolute or relative to the spec file directory), layered in order before `values`
+	ValuesFiles []string `json:"valuesFiles"`
 	// all user-supplied values in one inline yaml document
-	Values string `json´:"values"`
+	Values string `json:"values"`
+	// the inline values of the spec defaults, layered after the values files of
+	// the defaults and before the test case's own values files
+	DefaultValues string `json:"-"`
+	// kubernetes version used for `.Capabilities.KubeVersion`, i.e. `1.25.0`
+	KubeVersion string `json:"kubeVersion"`
+	// api versions added to `.Capabilities.APIVersions`, i.e. `monitoring.coreos.com/v1`
+	APIVersions []string `json:"apiVersions"`
 	// extra arguments passed through to the helm CLI, i.e. ["--set-file", "foo=foo.txt"]
 	ExtraArgs []string `json:"extraArgs"`
 	// require rendering to fail for the test to pass
 	ShouldFailToRender bool `json:"shouldFailToRender"`
+	// require rendering to fail with an error containing this substring
+	// or matching it as a regular expression, implies `shouldFailToRender`
+	ExpectedError string `json:"expectedError"`
+	// number of values files inherited from the spec defaults
+	inheritedValuesFiles int
+}
+
+// returns true if the test case requires rendering to fail
+func (r RenderInstructions) ExpectsFailure() bool {
+	return r.ShouldFailToRender || r.ExpectedError != ""
+}
+
+// returns true if the message of a render error contains the expected error
+// or matches it as a regular expression
+func (r RenderInstructions) matchesError(err error) bool {
+	message := RenderErrorMessage(err)
+	if strings.Contains(message, r.ExpectedError) {
+		return true
+	}
+	expr, compileErr := regexp.Compile(r.ExpectedError)
+	return compileErr == nil && expr.MatchString(message)
+}
+
+// a failed `helm template` run, including what helm wrote to stderr
+type RenderError struct {
+	Err    error
+	Stderr string
+}
+
+func (e *RenderError) Error() string {
+	stderr := strings.TrimSpace(e.Stderr)
+	if stderr == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v: %v", e.Err, stderr)
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// returns the error message helm reported for a failed rendering,
+// falling back to the error itself if helm did not write to stderr
+func RenderErrorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	var renderErr *RenderError
+	if errors.As(err, &renderErr) && strings.TrimSpace(renderErr.Stderr) != "" {
+		return strings.TrimSpace(renderErr.Stderr)
+	}
+	return err.Error()
+}
+
+const (
+	// renders charts by running the `helm` binary on the PATH
+	RendererCLI = "cli"
+	// renders charts in-process with the helm SDK helm-spec was built with
+	RendererSDK = "sdk"
+)
+
+var AllowedRenderers = [...]string{RendererCLI, RendererSDK}
+
+// the helm binary used by the CLI renderer if none is configured
+const DefaultHelmBinary = "helm"
+
+// makes relative values file paths absolute and verifies that all values files exist
+func (r *RenderInstructions) resolveValuesFiles(specDir string) error {
+	for idx, f := range r.ValuesFiles {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(specDir, f)
+		}
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("values file `%v` does not seem to exist: %w", r.ValuesFiles[idx], err)
+		}
+		if info.IsDir() {
+			return fmt.Errorf("values file `%v` is a directory", r.ValuesFiles[idx])
+		}
+		r.ValuesFiles[idx] = f
+	}
+	return nil
 }
 
-// runs helm dependency build and helm template, returning the
-// rendered manifest or error
-func (r RenderInstructions) Execute(chartPath string) (manifest string, err error) {
-	helmDepBuildArgs := []string{"dependency", "build", chartPath}
-	helmDepBuild := exec.Command("helm", helmDepBuildArgs...)
-	err = helmDepBuild.Run()
+// rejects kube versions helm cannot parse before any rendering
+func (r RenderInstructions) validateKubeVersion() error {
+	if r.KubeVersion == "" {
+		return nil
+	}
+	if _, err := chartutil.ParseKubeVersion(r.KubeVersion); err != nil {
+		return fmt.Errorf("invalid kube version `%v`: %w", r.KubeVersion, err)
+	}
+	return nil
+}
+
+// applies spec-level defaults to the render instructions of a test case.
+// Values files, api versions and extra arguments of the defaults come first, the inline
+// values of the defaults are layered between the values files of the defaults and those
+// of the test case. Release name, namespace and kube version are only inherited if not set.
+// `shouldFailToRender` and `expectedError` are never inherited
+func (r RenderInstructions) withDefaults(defaults RenderInstructions) RenderInstructions {
+	if r.ReleaseName == "" {
+		r.ReleaseName = defaults.ReleaseName
+	}
+	if r.Namespace == "" {
+		r.Namespace = defaults.Namespace
+	}
+	if r.KubeVersion == "" {
+		r.KubeVersion = defaults.KubeVersion
+	}
+	r.ValuesFiles = append(append([]string{}, defaults.ValuesFiles...), r.ValuesFiles...)
+	r.inheritedValuesFiles = len(defaults.ValuesFiles)
+	r.DefaultValues = defaults.Values
+	r.APIVersions = append(append([]string{}, defaults.APIVersions...), r.APIVersions...)
+	r.ExtraArgs = append(append([]string{}, defaults.ExtraArgs...), r.ExtraArgs...)
+	return r
+}
+
+// returns the values files in the order they are passed to helm, with the file
+// holding the default values inserted after the values files of the defaults
+func (r RenderInstructions) layeredValuesFiles(defaultValuesFile string) []string {
+	if defaultValuesFile == "" {
+		return append([]string{}, r.ValuesFiles...)
+	}
+	inherited := r.inheritedValuesFiles
+	if inherited > len(r.ValuesFiles) {
+		inherited = len(r.ValuesFiles)
+	}
+	files := append([]string{}, r.ValuesFiles[:inherited]...)
+	files = append(files, defaultValuesFile)
+	return append(files, r.ValuesFiles[inherited:]...)
+}
+
+// writes the default values to a temporary values file that only exists while fn runs,
+// fn gets an empty path if there are no default values
+func (r RenderInstructions) withDefaultValuesFile(fn func(defaultValuesFile string) error) error {
+	if strings.TrimSpace(r.DefaultValues) == "" {
+		return fn("")
+	}
+	f, err := os.CreateTemp("", "helm-spec-defaults-*.yaml")
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(r.DefaultValues)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return fn(f.Name())
+}
+
+// renders a chart based on render instructions
+type Renderer interface {
+	Render(ctx context.Context, r RenderInstructions, chartPath string) (manifest string, err error)
+}
+
+// returns the renderer with the given name, defaults to the CLI renderer.
+// The CLI renderer runs helmBinary, or `helm` on the PATH if empty
+func NewRenderer(name string, helmBinary string) (Renderer, error) {
+	switch name {
+	case RendererCLI, "":
+		return CLIRenderer{Binary: helmBinary}, nil
+	case RendererSDK:
+		return SDKRenderer{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported renderer `%v`", name)
+	}
+}
+
+// renders charts with `helm template`
+type CLIRenderer struct {
+	// path or name of the helm binary, defaults to `helm` on the PATH
+	Binary string
+}
+
+// runs `helm dependency build` with the helm binary of the renderer
+func (c CLIRenderer) BuildDependencies(ctx context.Context, chartPath string) error {
+	binary := c.Binary
+	if binary == "" {
+		binary = DefaultHelmBinary
+	}
+	return BuildDependencies(ctx, binary, chartPath)
+}
+
+func (c CLIRenderer) Render(ctx context.Context, r RenderInstructions, chartPath string) (string, error) {
+	binary := c.Binary
+	if binary == "" {
+		binary = DefaultHelmBinary
+	}
+	return r.execute(ctx, binary, chartPath)
+}
+
+// runs helm template, returning the rendered manifest or error.
+// chart dependencies must already be built, see BuildDependencies.
+// The helm process is killed when ctx is done
+func (r RenderInstructions) Execute(ctx context.Context, chartPath string) (manifest string, err error) {
+	return r.execute(ctx, DefaultHelmBinary, chartPath)
+}
 
+func (r RenderInstructions) execute(ctx context.Context, helmBinary string, chartPath string) (manifest string, err error) {
+	err = r.withDefaultValuesFile(func(defaultValuesFile string) error {
+		helmTemplate := exec.CommandContext(ctx, helmBinary, r.helmTemplateArgs(chartPath, defaultValuesFile)...)
+		helmTemplate.Stdin = strings.NewReader(r.Values)
+		out := &strings.Builder{}
+		stderr := &strings.Builder{}
+		helmTemplate.Stdout = out
+		helmTemplate.Stderr = stderr
+		err := helmTemplate.Run()
+		manifest = out.String()
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return &RenderError{Err: err, Stderr: stderr.String()}
+		}
+		return nil
+	})
+	return manifest, err
+}
+
+// returns the arguments for `helm template`, reading inline values from stdin
+// and the default values from defaultValuesFile
+func (r RenderInstructions) helmTemplateArgs(chartPath string, defaultValuesFile string) []string {
 	helmTemplateArgs := []string{"template"}
 	if r.ReleaseName != "" {
 		helmTemplateArgs = append(helmTemplateArgs, r.ReleaseName)
@@ -37,12 +268,65 @@ func (r RenderInstructions) Execute(chartPath string) (manifest string, err erro
 	if r.Namespace != "" {
 		helmTemplateArgs = append(helmTemplateArgs, "-n", r.Namespace)
 	}
+	for _, f := range r.layeredValuesFiles(defaultValuesFile) {
+		helmTemplateArgs = append(helmTemplateArgs, "-f", f)
+	}
+	if r.KubeVersion != "" {
+		helmTemplateArgs = append(helmTemplateArgs, "--kube-version", r.KubeVersion)
+	}
+	for _, v := range r.APIVersions {
+		helmTemplateArgs = append(helmTemplateArgs, "--api-versions", v)
+	}
 	helmTemplateArgs = append(helmTemplateArgs, r.ExtraArgs...)
 	helmTemplateArgs = append(helmTemplateArgs, "-f", "-")
-	helmTemplate := exec.Command("helm", helmTemplateArgs...)
-	helmTemplate.Stdin = strings.NewReader(r.Values)
-	out := &strings.Builder{}
-	helmTemplate.Stdout = out
-	err = helmTemplate.Run()
-	return out.String(), err
+	return helmTemplateArgs
+}
+
+// returns a shell command line that reproduces the rendering with the helm CLI.
+// Inline values are passed to stdin with a heredoc, default values with a heredoc
+// on file descriptor 3
+func (r RenderInstructions) Command(helmBinary string, chartPath string) string {
+	defaultValues := strings.TrimRight(r.DefaultValues, "\n")
+	defaultValuesFile := ""
+	if strings.TrimSpace(defaultValues) != "" {
+		defaultValuesFile = "/dev/fd/3"
+	}
+	quoted := []string{shellQuote(helmBinary)}
+	for _, arg := range r.helmTemplateArgs(chartPath, defaultValuesFile) {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	command := strings.Join(quoted, " ")
+	heredocs := []string{}
+	if defaultValuesFile != "" {
+		delimiter := heredocDelimiter("DEFAULTS", defaultValues)
+		command += fmt.Sprintf(" 3<<'%v'", delimiter)
+		heredocs = append(heredocs, defaultValues, delimiter)
+	}
+	values := strings.TrimRight(r.Values, "\n")
+	if values == "" {
+		command += " < /dev/null"
+	} else {
+		delimiter := heredocDelimiter("VALUES", values)
+		command += fmt.Sprintf(" <<'%v'", delimiter)
+		heredocs = append(heredocs, values, delimiter)
+	}
+	return strings.Join(append([]string{command}, heredocs...), "\n")
+}
+
+// returns a heredoc delimiter that does not occur in the content
+func heredocDelimiter(delimiter string, content string) string {
+	for strings.Contains(content, delimiter) {
+		delimiter += "_"
+	}
+	return delimiter
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// quotes an argument for POSIX shells unless it only contains safe characters
+func shellQuote(arg string) string {
+	if shellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
 }
@@ -0,0 +1,287 @@
+package helmspec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/strvals"
+	"sigs.k8s.io/yaml"
+)
+
+// release name used by `helm template` if none is given
+const defaultReleaseName = "release-name"
+
+// renders charts in-process with the helm SDK, mirroring `helm template`
+type SDKRenderer struct{}
+
+// the subset of `helm template` flags the SDK renderer understands in `extraArgs`
+type sdkTemplateOptions struct {
+	valueFiles   []string
+	values       []string
+	stringValues []string
+	fileValues   []string
+	jsonValues   []string
+	kubeVersion  string
+	apiVersions  []string
+	includeCRDs  bool
+	skipTests    bool
+	isUpgrade    bool
+	disableHooks bool
+}
+
+// parses `extraArgs` into template options, rejecting flags the SDK renderer does not support
+func parseSDKTemplateOptions(args []string) (opts sdkTemplateOptions, err error) {
+	stringFlags := map[string]func(string){
+		"-f":             func(v string) { opts.valueFiles = append(opts.valueFiles, v) },
+		"--values":       func(v string) { opts.valueFiles = append(opts.valueFiles, v) },
+		"--set":          func(v string) { opts.values = append(opts.values, v) },
+		"--set-string":   func(v string) { opts.stringValues = append(opts.stringValues, v) },
+		"--set-file":     func(v string) { opts.fileValues = append(opts.fileValues, v) },
+		"--set-json":     func(v string) { opts.jsonValues = append(opts.jsonValues, v) },
+		"--kube-version": func(v string) { opts.kubeVersion = v },
+		"-a":             func(v string) { opts.apiVersions = append(opts.apiVersions, v) },
+		"--api-versions": func(v string) { opts.apiVersions = append(opts.apiVersions, v) },
+	}
+	boolFlags := map[string]*bool{
+		"--include-crds": &opts.includeCRDs,
+		"--skip-tests":   &opts.skipTests,
+		"--is-upgrade":   &opts.isUpgrade,
+		"--no-hooks":     &opts.disableHooks,
+	}
+	for idx := 0; idx < len(args); idx++ {
+		name, value, hasValue := strings.Cut(args[idx], "=")
+		if flag, ok := boolFlags[name]; ok {
+			*flag = !hasValue || value == "true"
+			continue
+		}
+		set, ok := stringFlags[name]
+		if !ok {
+			return opts, fmt.Errorf("extra argument `%v` is not supported by the `%v` renderer", args[idx], RendererSDK)
+		}
+		if !hasValue {
+			if idx+1 >= len(args) {
+				return opts, fmt.Errorf("extra argument `%v` requires a value", name)
+			}
+			idx++
+			value = args[idx]
+		}
+		set(value)
+	}
+	return opts, nil
+}
+
+// picks the flags setting values from `extraArgs`, all other flags are ignored
+func parseValuesFlags(args []string) (opts sdkTemplateOptions, err error) {
+	valuesFlags := map[string]bool{"-f": true, "--values": true, "--set": true, "--set-string": true, "--set-file": true, "--set-json": true}
+	picked := []string{}
+	for idx := 0; idx < len(args); idx++ {
+		name, _, hasValue := strings.Cut(args[idx], "=")
+		if !valuesFlags[name] {
+			continue
+		}
+		picked = append(picked, args[idx])
+		if !hasValue && idx+1 < len(args) {
+			idx++
+			picked = append(picked, args[idx])
+		}
+	}
+	return parseSDKTemplateOptions(picked)
+}
+
+// merges user-supplied values the same way `helm template` merges
+// `-f` files, the inline values document and `--set` flags
+func (opts sdkTemplateOptions) mergeValues(inlineValues string) (base map[string]interface{}, err error) {
+	base = map[string]interface{}{}
+	valueFiles := [][]byte{}
+	for _, f := range opts.valueFiles {
+		content, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		valueFiles = append(valueFiles, content)
+	}
+	// the inline values are passed to `helm template` as the last values file
+	valueFiles = append(valueFiles, []byte(inlineValues))
+	for _, content := range valueFiles {
+		current := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &current); err != nil {
+			return nil, fmt.Errorf("failed to parse values: %w", err)
+		}
+		base = mergeValues(base, current)
+	}
+	for _, v := range opts.jsonValues {
+		if err := strvals.ParseJSON(v, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --set-json data %v: %w", v, err)
+		}
+	}
+	for _, v := range opts.values {
+		if err := strvals.ParseInto(v, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --set data: %w", err)
+		}
+	}
+	for _, v := range opts.stringValues {
+		if err := strvals.ParseIntoString(v, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --set-string data: %w", err)
+		}
+	}
+	for _, v := range opts.fileValues {
+		reader := func(rs []rune) (interface{}, error) {
+			content, err := os.ReadFile(string(rs))
+			return string(content), err
+		}
+		if err := strvals.ParseIntoFile(v, base, reader); err != nil {
+			return nil, fmt.Errorf("failed parsing --set-file data: %w", err)
+		}
+	}
+	return base, nil
+}
+
+// merges the values files, default values and inline values of the render
+// instructions with the values flags of opts like `helm template` does
+func (r RenderInstructions) mergeValues(opts sdkTemplateOptions) (vals map[string]interface{}, err error) {
+	err = r.withDefaultValuesFile(func(defaultValuesFile string) error {
+		opts.valueFiles = append(r.layeredValuesFiles(defaultValuesFile), opts.valueFiles...)
+		vals, err = opts.mergeValues(r.Values)
+		return err
+	})
+	return vals, err
+}
+
+func isTestHook(h *release.Hook) bool {
+	for _, e := range h.Events {
+		if e == release.HookTest {
+			return true
+		}
+	}
+	return false
+}
+
+// builds the dependencies of a chart in-process like `helm dependency build`, using the
+// repository configuration of the helm environment variables. The build cannot be
+// interrupted once it started, the `charts/` directory must not be left half-written
+func (SDKRenderer) BuildDependencies(ctx context.Context, chartPath string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to build dependencies of chart %v: %w", chartPath, err)
+	}
+	settings := cli.New()
+	registryClient, err := registry.NewClient(registry.ClientOptCredentialsFile(settings.RegistryConfig))
+	if err != nil {
+		return fmt.Errorf("failed to build dependencies of chart %v: %w", chartPath, err)
+	}
+	manager := &downloader.Manager{
+		Out:              io.Discard,
+		ChartPath:        chartPath,
+		Getters:          getter.All(settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+	if err := manager.Build(); err != nil {
+		return fmt.Errorf("failed to build dependencies of chart %v: %w", chartPath, err)
+	}
+	return nil
+}
+
+// rendering in-process cannot be interrupted, when ctx is done Render returns
+// immediately and the abandoned rendering finishes in the background
+func (s SDKRenderer) Render(ctx context.Context, r RenderInstructions, chartPath string) (manifest string, err error) {
+	type output struct {
+		manifest string
+		err      error
+	}
+	done := make(chan output, 1)
+	go func() {
+		manifest, err := s.render(r, chartPath)
+		done <- output{manifest, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case out := <-done:
+		return out.manifest, out.err
+	}
+}
+
+func (SDKRenderer) render(r RenderInstructions, chartPath string) (manifest string, err error) {
+	opts, err := parseSDKTemplateOptions(r.ExtraArgs)
+	if err != nil {
+		return "", err
+	}
+	opts.apiVersions = append(append([]string{}, r.APIVersions...), opts.apiVersions...)
+	// like with the helm CLI, `--kube-version` in `extraArgs` comes last and wins
+	if opts.kubeVersion == "" {
+		opts.kubeVersion = r.KubeVersion
+	}
+	vals, err := r.mergeValues(opts)
+	if err != nil {
+		return "", err
+	}
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		return "", err
+	}
+	switch chart.Metadata.Type {
+	case "", "application":
+	default:
+		return "", fmt.Errorf("%v charts are not installable", chart.Metadata.Type)
+	}
+	if req := chart.Metadata.Dependencies; req != nil {
+		if err := action.CheckDependencies(chart, req); err != nil {
+			return "", fmt.Errorf("an error occurred while checking for chart dependencies: %w", err)
+		}
+	}
+
+	client := action.NewInstall(&action.Configuration{
+		Log: func(string, ...interface{}) {},
+	})
+	client.DryRun = true
+	client.ClientOnly = true
+	client.Replace = true
+	client.ReleaseName = r.ReleaseName
+	if client.ReleaseName == "" {
+		client.ReleaseName = defaultReleaseName
+	}
+	client.Namespace = r.Namespace
+	if client.Namespace == "" {
+		client.Namespace = "default"
+	}
+	client.IncludeCRDs = opts.includeCRDs
+	client.IsUpgrade = opts.isUpgrade
+	client.DisableHooks = opts.disableHooks
+	client.APIVersions = chartutil.VersionSet(opts.apiVersions)
+	if opts.kubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(opts.kubeVersion)
+		if err != nil {
+			return "", fmt.Errorf("invalid kube version '%v': %w", opts.kubeVersion, err)
+		}
+		client.KubeVersion = kubeVersion
+	}
+	rel, err := client.Run(chart, vals)
+	if err != nil {
+		return "", err
+	}
+
+	// same output format as `helm template`
+	out := &strings.Builder{}
+	fmt.Fprintln(out, strings.TrimSpace(rel.Manifest))
+	if !client.DisableHooks {
+		for _, h := range rel.Hooks {
+			if opts.skipTests && isTestHook(h) {
+				continue
+			}
+			fmt.Fprintf(out, "---\n# Source: %v\n%v\n", h.Path, h.Manifest)
+		}
+	}
+	return out.String(), nil
+}
@@ -1,7 +1,10 @@
 package helmspec
 
 import (
+	"context"
 	"os"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,9 +13,154 @@ import (
 func TestExecuteRenderInstructions(t *testing.T) {
 	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
 	assert.NoError(t, err)
-	actualManifest, err := spec.TestCases[0].Render.Execute("./testdata/charts/example")
+	actualManifest, err := spec.TestCases[0].Render.Execute(context.Background(), "./testdata/charts/example")
 	assert.NoError(t, err)
 	expectedManifest, err := os.ReadFile("./testdata/example_spec_0_manifest.yaml")
 	assert.NoError(t, err)
 	assert.Equal(t, string(expectedManifest), actualManifest)
 }
+
+func TestCommandReproducesRendering(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
+	assert.NoError(t, err)
+	r := spec.TestCases[0].Render
+	r.ExtraArgs = []string{"--set", "podAnnotations.note=it's quoted"}
+	expectedManifest, err := r.Execute(context.Background(), "./testdata/charts/example")
+	assert.NoError(t, err)
+	command := r.Command(DefaultHelmBinary, "./testdata/charts/example")
+	assert.Contains(t, command, `'podAnnotations.note=it'\''s quoted'`)
+	out, err := exec.Command("sh", "-c", command).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedManifest, string(out))
+}
+
+func TestExecuteCapturesStderr(t *testing.T) {
+	r := RenderInstructions{}
+	_, err := r.Execute(context.Background(), "./not/an/existing/chart")
+	var renderErr *RenderError
+	assert.ErrorAs(t, err, &renderErr)
+	assert.Contains(t, renderErr.Stderr, "not/an/existing/chart")
+	assert.Contains(t, err.Error(), "exit status 1: Error:")
+	assert.Equal(t, strings.TrimSpace(renderErr.Stderr), RenderErrorMessage(err))
+}
+
+func TestExecuteKillsHelmWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := RenderInstructions{}.Execute(ctx, "./testdata/charts/example")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCLIRendererUsesConfiguredBinary(t *testing.T) {
+	_, err := CLIRenderer{Binary: "./not/an/existing/helm"}.Render(context.Background(), RenderInstructions{}, "./testdata/charts/example")
+	assert.ErrorContains(t, err, "./not/an/existing/helm")
+}
+
+func TestSDKRendererMatchesCLIRenderer(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
+	assert.NoError(t, err)
+	actualManifest, err := SDKRenderer{}.Render(context.Background(), spec.TestCases[0].Render, "./testdata/charts/example")
+	assert.NoError(t, err)
+	expectedManifest, err := os.ReadFile("./testdata/example_spec_0_manifest.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expectedManifest), actualManifest)
+}
+
+func TestSDKRendererSupportsSetFlags(t *testing.T) {
+	r := RenderInstructions{
+		ReleaseName: "foo",
+		Values:      "image:\n  tag: 1.2.3\n",
+		ExtraArgs:   []string{"--set", "image.repository=test", "--set-string=image.tag=4.5.6"},
+	}
+	manifest, err := SDKRenderer{}.Render(context.Background(), r, "./testdata/charts/example")
+	assert.NoError(t, err)
+	image, err := EvalYQ(`select(.kind=="Deployment") | .spec.template.spec.containers[0].image`, manifest)
+	assert.NoError(t, err)
+	assert.Equal(t, "test:4.5.6", strings.TrimSpace(image))
+}
+
+func TestSDKRendererRejectsUnsupportedExtraArgs(t *testing.T) {
+	r := RenderInstructions{ExtraArgs: []string{"--post-renderer", "foo"}}
+	_, err := SDKRenderer{}.Render(context.Background(), r, "./testdata/charts/example")
+	assert.ErrorContains(t, err, "not supported")
+}
+
+func TestSDKRendererFailsForMissingChart(t *testing.T) {
+	_, err := SDKRenderer{}.Render(context.Background(), RenderInstructions{}, "./not/an/existing/chart")
+	assert.Error(t, err)
+}
+
+func TestNewRenderer(t *testing.T) {
+	renderer, err := NewRenderer(RendererSDK, "")
+	assert.NoError(t, err)
+	assert.IsType(t, SDKRenderer{}, renderer)
+	renderer, err = NewRenderer(RendererCLI, "/opt/helm/bin/helm")
+	assert.NoError(t, err)
+	assert.Equal(t, CLIRenderer{Binary: "/opt/helm/bin/helm"}, renderer)
+	_, err = NewRenderer("foo", "")
+	assert.Error(t, err)
+}
+
+func TestSDKRendererLayersValuesFiles(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/values_files_spec.yaml")
+	assert.NoError(t, err)
+	result := spec.TestCases[0].Execute(context.Background(), spec.ChartPath, TestRunSettings{Renderer: SDKRenderer{}})
+	assert.NoError(t, result.Error)
+	assert.True(t, result.Succeeded)
+}
+
+func TestRenderInstructionsWithDefaults(t *testing.T) {
+	defaults := RenderInstructions{
+		ReleaseName: "foo",
+		Namespace:   "default",
+		ValuesFiles: []string{"defaults.yaml"},
+		Values:      "image:\n  repository: test\n  tag: 1.0.0\nreplicaCount: 2\n",
+		ExtraArgs:   []string{"--set", "a=b"},
+	}
+	r := RenderInstructions{
+		Namespace:   "other",
+		ValuesFiles: []string{"testcase.yaml"},
+		Values:      "image:\n  tag: 1.2.3\n",
+		ExtraArgs:   []string{"--set", "c=d"},
+	}
+	merged := r.withDefaults(defaults)
+	assert.Equal(t, "foo", merged.ReleaseName)
+	assert.Equal(t, "other", merged.Namespace)
+	assert.Equal(t, []string{"defaults.yaml", "testcase.yaml"}, merged.ValuesFiles)
+	assert.Equal(t, []string{"defaults.yaml", "/tmp/defaults.yaml", "testcase.yaml"}, merged.layeredValuesFiles("/tmp/defaults.yaml"))
+	assert.Equal(t, []string{"--set", "a=b", "--set", "c=d"}, merged.ExtraArgs)
+	assert.Equal(t, defaults.Values, merged.DefaultValues)
+	assert.Equal(t, r.Values, merged.Values)
+}
+
+func TestDefaultValuesAreLayeredBeforeValuesFiles(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/defaults_spec.yaml")
+	assert.NoError(t, err)
+	// the image repository of the defaults is overridden by the values file of the test case
+	testCase := spec.TestCases[1]
+	for _, renderer := range []Renderer{CLIRenderer{}, SDKRenderer{}} {
+		result := testCase.Execute(context.Background(), spec.ChartPath, TestRunSettings{Renderer: renderer})
+		assert.NoError(t, result.Error)
+		assert.True(t, result.Succeeded)
+	}
+	// the command passes the default values on file descriptor 3
+	out, err := exec.Command("sh", "-c", testCase.Render.Command(DefaultHelmBinary, spec.ChartPath)).Output()
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "image: \"ci:1.2.3\"")
+	assert.Contains(t, string(out), "imagePullPolicy: Always")
+}
+
+func TestKubeVersionAndAPIVersions(t *testing.T) {
+	r := RenderInstructions{
+		Values:      "ingress:\n  enabled: true\n",
+		KubeVersion: "1.18.0",
+		APIVersions: []string{"monitoring.coreos.com/v1"},
+	}
+	command := r.Command(DefaultHelmBinary, "./testdata/charts/example")
+	assert.Contains(t, command, "--kube-version 1.18.0 --api-versions monitoring.coreos.com/v1")
+	for _, renderer := range []Renderer{CLIRenderer{}, SDKRenderer{}} {
+		manifest, err := renderer.Render(context.Background(), r, "./testdata/charts/example")
+		assert.NoError(t, err)
+		assert.Contains(t, manifest, "apiVersion: networking.k8s.io/v1beta1\nkind: Ingress")
+	}
+}
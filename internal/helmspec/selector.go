@@ -0,0 +1,121 @@
+package helmspec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// selects the rendered documents an assertion's query runs against.
+// All fields that are set must match
+type DocumentSelector struct {
+	// the `kind` of the document, i.e. `Deployment`
+	Kind string `json:"kind,omitempty"`
+	// the `metadata.name` of the document
+	Name string `json:"name,omitempty"`
+	// the `apiVersion` of the document, i.e. `apps/v1`
+	APIVersion string `json:"apiVersion,omitempty"`
+	// labels the document must have in `metadata.labels`
+	Labels map[string]string `json:"labels,omitempty"`
+	// the template the document was rendered from, relative to the chart directory
+	// like `templates/service.yaml` or as in the `# Source:` comment
+	Template string `json:"template,omitempty"`
+	// allow more than one document to match, by default exactly one document must match
+	Multiple bool `json:"multiple,omitempty"`
+}
+
+// the fields of a rendered document a selector can match
+type documentMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+	} `json:"metadata"`
+}
+
+// describes the selector, i.e. `{kind=Deployment, labels.app=web}`
+func (s DocumentSelector) String() string {
+	pairs := []string{}
+	for _, field := range [][2]string{
+		{"kind", s.Kind},
+		{"name", s.Name},
+		{"apiVersion", s.APIVersion},
+		{"template", s.Template},
+	} {
+		if field[1] != "" {
+			pairs = append(pairs, field[0]+"="+field[1])
+		}
+	}
+	labels := []string{}
+	for key, value := range s.Labels {
+		labels = append(labels, "labels."+key+"="+value)
+	}
+	sort.Strings(labels)
+	return "{" + strings.Join(append(pairs, labels...), ", ") + "}"
+}
+
+// returns true if the document matches all fields of the selector
+func (s DocumentSelector) matches(document Document) (bool, error) {
+	if s.Template != "" {
+		template, _ := sourceTemplate(document.Source)
+		if s.Template != template && s.Template != document.Source {
+			return false, nil
+		}
+	}
+	meta := documentMeta{}
+	if err := yaml.Unmarshal([]byte(document.Content), &meta); err != nil {
+		return false, fmt.Errorf("failed to parse document from %v: %w", document.Source, err)
+	}
+	if (s.Kind != "" && s.Kind != meta.Kind) ||
+		(s.Name != "" && s.Name != meta.Metadata.Name) ||
+		(s.APIVersion != "" && s.APIVersion != meta.APIVersion) {
+		return false, nil
+	}
+	for key, value := range s.Labels {
+		if actual, ok := meta.Metadata.Labels[key]; !ok || actual != value {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// returns the documents of a manifest matching the selector, joined with document separators.
+// Fails if no document matches, or more than one if only one is allowed
+func (s DocumentSelector) Select(manifest string) (selected string, err error) {
+	documents := []string{}
+	for _, document := range SplitManifest(manifest) {
+		ok, err := s.matches(document)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			documents = append(documents, document.Content)
+		}
+	}
+	if len(documents) == 0 {
+		return "", fmt.Errorf("no document matches %v", s)
+	}
+	if len(documents) > 1 && !s.Multiple {
+		return "", fmt.Errorf("%v documents match %v, but exactly one was expected", len(documents), s)
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+// replaces `${name}` placeholders of matrix parameters in all fields of the selector
+func (s DocumentSelector) substituteParameters(parameters map[string]string) *DocumentSelector {
+	s.Kind = substituteParameters(s.Kind, parameters)
+	s.Name = substituteParameters(s.Name, parameters)
+	s.APIVersion = substituteParameters(s.APIVersion, parameters)
+	s.Template = substituteParameters(s.Template, parameters)
+	labels := map[string]string{}
+	for key, value := range s.Labels {
+		labels[key] = substituteParameters(value, parameters)
+	}
+	if s.Labels != nil {
+		s.Labels = labels
+	}
+	return &s
+}
@@ -0,0 +1,94 @@
+package helmspec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const selectorManifest = `---
+# Source: example/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo-example
+  labels:
+    app: example
+spec:
+  type: ClusterIP
+---
+# Source: example/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo-example
+  labels:
+    app: example
+spec:
+  replicas: 1
+---
+# Source: example/charts/redis/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: foo-redis
+spec:
+  type: NodePort
+`
+
+func TestDocumentSelectorMatches(t *testing.T) {
+	cases := []struct {
+		selector DocumentSelector
+		names    []string
+	}{
+		{selector: DocumentSelector{Kind: "Service", Multiple: true}, names: []string{"foo-example", "foo-redis"}},
+		{selector: DocumentSelector{Kind: "Service", Name: "foo-redis"}, names: []string{"foo-redis"}},
+		{selector: DocumentSelector{APIVersion: "apps/v1"}, names: []string{"foo-example"}},
+		{selector: DocumentSelector{Labels: map[string]string{"app": "example"}, Multiple: true}, names: []string{"foo-example", "foo-example"}},
+		{selector: DocumentSelector{Template: "templates/service.yaml"}, names: []string{"foo-example"}},
+		{selector: DocumentSelector{Template: "example/charts/redis/templates/service.yaml"}, names: []string{"foo-redis"}},
+	}
+	for _, c := range cases {
+		names := []string{}
+		for _, document := range SplitManifest(selectorManifest) {
+			ok, err := c.selector.matches(document)
+			assert.NoError(t, err)
+			if ok {
+				names = append(names, document.Content)
+			}
+		}
+		assert.Equal(t, len(c.names), len(names), c.selector.String())
+		selected, err := c.selector.Select(selectorManifest)
+		assert.NoError(t, err)
+		actual, err := EvalYQ(".metadata.name", selected)
+		assert.NoError(t, err)
+		for _, name := range c.names {
+			assert.Contains(t, actual, name, c.selector.String())
+		}
+	}
+}
+
+func TestDocumentSelectorFailsWithoutExactlyOneMatch(t *testing.T) {
+	_, err := DocumentSelector{Kind: "Ingress"}.Select(selectorManifest)
+	assert.EqualError(t, err, "no document matches {kind=Ingress}")
+	_, err = DocumentSelector{Kind: "Service", Labels: map[string]string{"app": "redis"}}.Select(selectorManifest)
+	assert.EqualError(t, err, "no document matches {kind=Service, labels.app=redis}")
+	_, err = DocumentSelector{Kind: "Service"}.Select(selectorManifest)
+	assert.EqualError(t, err, "2 documents match {kind=Service}, but exactly one was expected")
+}
+
+func TestAssertionWithDocumentSelector(t *testing.T) {
+	assertion := Assertion{
+		Document:       &DocumentSelector{Kind: "Service", Name: "foo-redis"},
+		Query:          ".spec.type",
+		ExpectedResult: "NodePort",
+	}
+	result := assertion.Evaluate(selectorManifest)
+	assert.True(t, result.Succeeded)
+	assert.Equal(t, "NodePort", result.ActualResult)
+
+	assertion.Document = &DocumentSelector{Kind: "Service", Name: "foo-memcached"}
+	result = assertion.Evaluate(selectorManifest)
+	assert.False(t, result.Succeeded)
+	assert.EqualError(t, result.Error, "no document matches {kind=Service, name=foo-memcached}")
+}
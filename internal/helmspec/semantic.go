@@ -0,0 +1,65 @@
+package helmspec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// parses a yaml value and prints it with sorted keys and uniform
+// quoting and indentation, so equal values have equal representations
+func canonicalYAML(value string) (string, error) {
+	var parsed interface{}
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return "", err
+	}
+	if parsed == nil {
+		return "null", nil
+	}
+	out, err := yaml.Marshal(parsed)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// returns true if two yaml values are equal after parsing
+func semanticallyEqual(expected string, actual string) (bool, error) {
+	var e, a interface{}
+	if err := yaml.Unmarshal([]byte(expected), &e); err != nil {
+		return false, fmt.Errorf("failed to parse expected result: %w", err)
+	}
+	if err := yaml.Unmarshal([]byte(actual), &a); err != nil {
+		return false, fmt.Errorf("failed to parse query output: %w", err)
+	}
+	return reflect.DeepEqual(e, a), nil
+}
+
+// compares the query output to a structured expected result,
+// reporting a diff between the canonical forms of both on mismatch
+func (a Assertion) evaluateStructured(result AssertionResult) AssertionResult {
+	equal, err := semanticallyEqual(a.ExpectedResult, result.ActualResult)
+	if err != nil {
+		result.Error = err
+		return result
+	}
+	switch a.ResolvedOperator() {
+	case OperatorEquals:
+		result.Succeeded = equal
+	case OperatorNotEquals:
+		result.Succeeded = !equal
+	default:
+		result.Error = fmt.Errorf("operator `%v` is not supported for structured expected results", a.ResolvedOperator())
+		return result
+	}
+	if !equal {
+		actual, err := canonicalYAML(result.ActualResult)
+		if err != nil {
+			actual = result.ActualResult
+		}
+		result.Diff = UnifiedDiff(a.ExpectedResult+"\n", actual+"\n", "expected", "actual")
+	}
+	return result
+}
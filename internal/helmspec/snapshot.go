@@ -0,0 +1,124 @@
+package helmspec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// directory next to the spec file where snapshots are stored
+const snapshotDir = "__snapshots__"
+
+type SnapshotResult struct {
+	// path of the stored snapshot file
+	Path      string `json:"path"`
+	Succeeded bool   `json:"succeeded"`
+	// the snapshot file was written in this run because snapshots are being updated
+	Updated bool `json:"updated"`
+	// unified diff between the stored snapshot and the rendered manifest
+	Diff  string `json:"diff,omitempty"`
+	Error error  `json:"error"`
+}
+
+var nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+
+// turns a test case title into a file name
+func slugify(title string) string {
+	slug := strings.Trim(nonAlphanumeric.ReplaceAllString(strings.ToLower(title), "_"), "_")
+	if slug == "" {
+		return "untitled"
+	}
+	return slug
+}
+
+// returns the path of the snapshot file for a test case, i.e.
+// `specs/__snapshots__/example_spec/when_overwriting_the_image.yaml`
+func (s HelmSpec) snapshotPath(t TestCase) string {
+	specName := strings.TrimSuffix(filepath.Base(s.FilePath), filepath.Ext(s.FilePath))
+	return filepath.Join(filepath.Dir(s.FilePath), snapshotDir, specName, slugify(t.Title)+".yaml")
+}
+
+// makes sure no two snapshot test cases of a spec share a snapshot file,
+// i.e. because their titles only differ in case or punctuation
+func (s HelmSpec) checkSnapshotPaths() error {
+	titles := map[string]string{}
+	for _, t := range s.TestCases {
+		if !t.Snapshot {
+			continue
+		}
+		path := s.snapshotPath(t)
+		if title, ok := titles[path]; ok {
+			return fmt.Errorf("test cases `%v` and `%v` in %v share the snapshot file %v, use distinct titles", title, t.Title, s.FilePath, path)
+		}
+		titles[path] = t.Title
+	}
+	return nil
+}
+
+// returns a unified diff between an expected and an actual text,
+// or an empty string if they are equal
+func UnifiedDiff(expected string, actual string, expectedName string, actualName string) string {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(expected),
+		B:        splitLines(actual),
+		FromFile: expectedName,
+		ToFile:   actualName,
+		Context:  3,
+	})
+	if err != nil {
+		return ""
+	}
+	return diff
+}
+
+// splits a text into lines that keep their line break. Unlike difflib.SplitLines
+// it does not add an empty line after a trailing line break
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(text, "\n"))
+}
+
+func writeSnapshot(path string, manifest string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(manifest), 0o644)
+}
+
+// compares a rendered manifest to the stored snapshot. Snapshots are only
+// written if `update` is set, missing snapshots fail otherwise
+func matchSnapshot(path string, manifest string, update bool) (result SnapshotResult) {
+	result.Path = path
+	stored, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		result.Error = fmt.Errorf("failed to read snapshot: %w", err)
+		return result
+	}
+	if err != nil && !update {
+		result.Error = errors.New("snapshot does not exist, rerun with `--update-snapshots` to write it")
+		return result
+	}
+	if err == nil && !update {
+		result.Diff = UnifiedDiff(string(stored), manifest, "snapshot", "rendered")
+		result.Succeeded = string(stored) == manifest
+		return result
+	}
+	if err == nil && string(stored) == manifest {
+		result.Succeeded = true
+		return result
+	}
+	if err = writeSnapshot(path, manifest); err != nil {
+		result.Error = fmt.Errorf("failed to write snapshot: %w", err)
+		return result
+	}
+	result.Succeeded = true
+	result.Updated = true
+	return result
+}
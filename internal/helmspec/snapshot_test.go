@@ -0,0 +1,116 @@
+package helmspec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writes a spec with a single snapshot test case for the example chart into a temporary directory
+func newSnapshotSpec(t *testing.T) *HelmSpec {
+	t.Helper()
+	chartPath, err := filepath.Abs("./testdata/charts/example")
+	assert.NoError(t, err)
+	specFile := filepath.Join(t.TempDir(), "snapshot_spec.yaml")
+	content := `
+title: snapshots
+chartPath: ` + chartPath + `
+testCases:
+- title: Default Values!
+  snapshot: true
+  render:
+    releaseName: foo
+`
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	spec, err := NewSpec(specFile)
+	assert.NoError(t, err)
+	return spec
+}
+
+func TestSnapshotPath(t *testing.T) {
+	spec := newSnapshotSpec(t)
+	expected := filepath.Join(filepath.Dir(spec.FilePath), "__snapshots__", "snapshot_spec", "default_values.yaml")
+	assert.Equal(t, expected, spec.snapshotPath(spec.TestCases[0]))
+}
+
+func TestSnapshotLifecycle(t *testing.T) {
+	spec := newSnapshotSpec(t)
+	path := spec.snapshotPath(spec.TestCases[0])
+
+	// missing snapshots fail without being written
+	result := spec.Execute(context.Background(), TestRunSettings{})
+	assert.False(t, result.Succeeded)
+	snapshot := result.TestCaseResults[0].Snapshot
+	assert.NotNil(t, snapshot)
+	assert.False(t, snapshot.Updated)
+	assert.ErrorContains(t, snapshot.Error, "snapshot does not exist")
+	assert.NoFileExists(t, path)
+
+	// missing snapshots are written when updating
+	result = spec.Execute(context.Background(), TestRunSettings{UpdateSnapshots: true})
+	assert.True(t, result.Succeeded)
+	assert.True(t, result.TestCaseResults[0].Snapshot.Updated)
+	stored, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, result.TestCaseResults[0].Manifest, string(stored))
+
+	// unchanged renders match
+	result = spec.Execute(context.Background(), TestRunSettings{})
+	assert.True(t, result.Succeeded)
+	assert.False(t, result.TestCaseResults[0].Snapshot.Updated)
+
+	// changed renders fail with a diff
+	assert.NoError(t, os.WriteFile(path, []byte(string(stored)+"foo: bar\n"), 0o644))
+	result = spec.Execute(context.Background(), TestRunSettings{})
+	assert.False(t, result.Succeeded)
+	assert.Contains(t, result.TestCaseResults[0].Snapshot.Diff, "-foo: bar")
+
+	// updating overwrites the stored snapshot
+	result = spec.Execute(context.Background(), TestRunSettings{UpdateSnapshots: true})
+	assert.True(t, result.Succeeded)
+	assert.True(t, result.TestCaseResults[0].Snapshot.Updated)
+	stored, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, result.TestCaseResults[0].Manifest, string(stored))
+}
+
+func TestUnifiedDiffHasNoTrailingBlankLine(t *testing.T) {
+	diff := UnifiedDiff("a: 1\nb: 2\n", "a: 1\nb: 3\n", "expected", "actual")
+	assert.Equal(t, "--- expected\n+++ actual\n@@ -1,2 +1,2 @@\n a: 1\n-b: 2\n+b: 3\n", diff)
+	assert.Equal(t, "", UnifiedDiff("a: 1\n", "a: 1\n", "expected", "actual"))
+}
+
+func TestSnapshotPathCollisionsAreRejected(t *testing.T) {
+	chartPath, err := filepath.Abs("./testdata/charts/example")
+	assert.NoError(t, err)
+	specFile := filepath.Join(t.TempDir(), "snapshot_spec.yaml")
+	content := `
+title: snapshots
+chartPath: ` + chartPath + `
+testCases:
+- title: Foo!
+  snapshot: true
+- title: foo
+  snapshot: true
+`
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	_, err = NewSpec(specFile)
+	assert.ErrorContains(t, err, "test cases `Foo!` and `foo`")
+	assert.ErrorContains(t, err, "share the snapshot file")
+
+	// test cases without snapshots may share a title slug
+	content = `
+title: snapshots
+chartPath: ` + chartPath + `
+testCases:
+- title: Foo!
+  snapshot: true
+- title: foo
+`
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	_, err = NewSpec(specFile)
+	assert.NoError(t, err)
+}
@@ -1,9 +1,14 @@
 package helmspec
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
+	yamlv3 "gopkg.in/yaml.v3"
 	"sigs.k8s.io/yaml"
 )
 
@@ -16,26 +21,93 @@ type TestCase struct {
 	Render RenderInstructions `json:"render"`
 	// assertions against the rendering output
 	Assertions []Assertion `json:"assertions"`
+	// compare the whole rendered manifest against a snapshot stored next to the spec file
+	Snapshot bool `json:"snapshot"`
+	// runs the test case once per combination of parameter values. `${name}` placeholders
+	// in the title, render instructions including those inherited from the defaults,
+	// queries, document selectors and expected results are replaced with the values.
+	// Unquoted placeholders making up a whole value in yaml are replaced with the typed value
+	Matrix map[string][]interface{} `json:"matrix"`
+	// tags to select test cases with the `--tags` and `--exclude-tags` flags
+	Tags []string `json:"tags"`
+	// maximum duration of the test case, i.e. `30s`, overrides the timeout of the spec
+	Timeout string `json:"timeout"`
+	// the parameter values of a test case expanded from a matrix
+	Parameters map[string]string `json:"-"`
+	// the parsed timeout
+	timeout time.Duration
 }
 
 type TestCaseResult struct {
 	Title            string             `json:"title"`
+	Parameters       map[string]string  `json:"parameters,omitempty"`
 	Render           RenderInstructions `json:"render"`
 	Succeeded        bool               `json:"succeeded"`
+	Skipped          bool               `json:"skipped,omitempty"`
 	Manifest         string             `json:"manifest"`
 	AssertionResults []AssertionResult  `json:"assertionResults"`
-	Error            error              `json:"error"`
+	Snapshot         *SnapshotResult    `json:"snapshot,omitempty"`
+	SchemaViolations []SchemaViolation  `json:"schemaViolations,omitempty"`
+	// `helm template` command line reproducing the rendering, including the values
+	Command string `json:"command,omitempty"`
+	// what helm wrote to stderr if rendering failed
+	Stderr string `json:"stderr,omitempty"`
+	Error  error  `json:"error"`
 }
 
-// renders a chart based on render instructions and evaluates assertions
-func (t TestCase) Execute(chartPath string) (result TestCaseResult) {
+// renders a chart based on render instructions, validates the rendered
+// manifest against kubernetes schemas if enabled and evaluates assertions
+func (t TestCase) Execute(ctx context.Context, chartPath string, settings TestRunSettings) (result TestCaseResult) {
+	manifest, err := settings.renderer().Render(ctx, t.Render, chartPath)
+	result = t.evaluate(manifest, err)
+	result.Command = t.Render.Command(settings.helmBinary(), chartPath)
+	if settings.SchemaValidator == nil || t.Render.ExpectsFailure() || result.Error != nil {
+		return result
+	}
+	result.SchemaViolations, result.Error = settings.SchemaValidator.Validate(manifest)
+	result.Succeeded = result.Succeeded && result.Error == nil && len(result.SchemaViolations) == 0
+	return result
+}
+
+// reports a test case that was filtered out as skipped
+func (t TestCase) skip() (result TestCaseResult) {
+	result.Title = t.Title
+	result.Parameters = t.Parameters
+	result.Render = t.Render
+	result.Skipped = true
+	// skipped test cases must not fail the test suite
+	result.Succeeded = true
+	return result
+}
+
+// reports a test case that could not be rendered, i.e. because the chart
+// dependencies failed to build. It fails even if rendering is expected to fail
+func (t TestCase) abort(err error) (result TestCaseResult) {
+	result.Title = t.Title
+	result.Parameters = t.Parameters
+	result.Render = t.Render
+	result.Error = err
+	result.Succeeded = false
+	return result
+}
+
+// evaluates assertions against the outcome of rendering the chart
+func (t TestCase) evaluate(manifest string, renderErr error) (result TestCaseResult) {
 	result.Title = t.Title
+	result.Parameters = t.Parameters
 	result.Render = t.Render
-	result.Manifest, result.Error = t.Render.Execute(chartPath)
+	result.Manifest, result.Error = manifest, renderErr
+	var helmErr *RenderError
+	if errors.As(renderErr, &helmErr) {
+		result.Stderr = helmErr.Stderr
+	}
 	// sometimes we want rendering to fail, i.e. to verify
 	// invalid values are rejected by the chart
-	if t.Render.ShouldFailToRender {
+	if t.Render.ExpectsFailure() {
 		result.Succeeded = result.Error != nil
+		if result.Succeeded && t.Render.ExpectedError != "" {
+			result.Succeeded = t.Render.matchesError(result.Error)
+		}
 		return result
 	}
 	if result.Error != nil {
@@ -64,8 +136,44 @@ type HelmSpec struct {
 	Title string `json:"title"`
 	// path to the helm chart (absolute or relative to the spec file directory)
 	ChartPath string `json:"chartPath"`
+	// render instructions inherited by every test case
+	Defaults RenderInstructions `json:"defaults"`
+	// runs every test case once per kubernetes version, i.e. ["1.24.0", "1.25.0"].
+	// Test cases with a `kubeVersion`, set by themselves or by the defaults, run only once
+	KubeVersions []string `json:"kubeVersions"`
+	// maximum duration of each test case, i.e. `30s`, overrides the `--timeout` flag
+	Timeout string `json:"timeout"`
 	// test cases to run for the helm chart
 	TestCases []TestCase `json:"testCases"`
+	// absolute path of the spec file the spec was loaded from
+	FilePath string `json:"-"`
+	// the parsed timeout
+	timeout time.Duration
+}
+
+// restores unquoted scalar expected results as they are written in the spec file,
+// converting yaml to json would normalize numbers like `1.10` to `1.1`
+func (s *HelmSpec) restoreScalarExpectedResults(content []byte) {
+	root := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(content, &root); err != nil || len(root.Content) == 0 {
+		return
+	}
+	testCases := mappingValue(resolveAlias(root.Content[0]), "testCases")
+	if testCases == nil || testCases.Kind != yamlv3.SequenceNode || len(testCases.Content) != len(s.TestCases) {
+		return
+	}
+	for i, testCase := range testCases.Content {
+		assertions := mappingValue(resolveAlias(testCase), "assertions")
+		if assertions == nil || assertions.Kind != yamlv3.SequenceNode || len(assertions.Content) != len(s.TestCases[i].Assertions) {
+			continue
+		}
+		for j, assertion := range assertions.Content {
+			expected := mappingValue(resolveAlias(assertion), "expectedResult")
+			if expected != nil && expected.Kind == yamlv3.ScalarNode && expected.Style == 0 && expected.Tag != "!!null" {
+				s.TestCases[i].Assertions[j].ExpectedResult = expected.Value
+			}
+		}
+	}
 }
 
 func NewSpec(filePath string) (spec *HelmSpec, err error) {
@@ -82,18 +190,120 @@ func NewSpec(filePath string) (spec *HelmSpec, err error) {
 	if err != nil {
 		return spec, err
 	}
+	spec.restoreScalarExpectedResults(content)
+	spec.FilePath = absFilePath
 	if !filepath.IsAbs(spec.ChartPath) {
 		spec.ChartPath = filepath.Join(filepath.Dir(absFilePath), spec.ChartPath)
 	}
+	if spec.Timeout != "" {
+		if spec.timeout, err = time.ParseDuration(spec.Timeout); err != nil {
+			return spec, fmt.Errorf("invalid timeout in %v: %w", absFilePath, err)
+		}
+	}
+	if err = spec.Defaults.resolveValuesFiles(filepath.Dir(absFilePath)); err != nil {
+		return spec, fmt.Errorf("defaults in %v: %w", absFilePath, err)
+	}
+	// matrix parameters are substituted after merging the defaults,
+	// so placeholders in the defaults are replaced as well
+	testCases := []TestCase{}
+	for _, testCase := range spec.TestCases {
+		testCase.Render = testCase.Render.withDefaults(spec.Defaults)
+		expanded, err := testCase.withKubeVersions(spec.KubeVersions).expandMatrix()
+		if err != nil {
+			return spec, fmt.Errorf("test case `%v` in %v: %w", testCase.Title, absFilePath, err)
+		}
+		testCases = append(testCases, expanded...)
+	}
+	spec.TestCases = testCases
+	for idx := range spec.TestCases {
+		testCase := &spec.TestCases[idx]
+		if err = testCase.Render.resolveValuesFiles(filepath.Dir(absFilePath)); err != nil {
+			return spec, fmt.Errorf("test case `%v` in %v: %w", testCase.Title, absFilePath, err)
+		}
+		if testCase.Timeout != "" {
+			if testCase.timeout, err = time.ParseDuration(testCase.Timeout); err != nil {
+				return spec, fmt.Errorf("test case `%v` in %v: invalid timeout: %w", testCase.Title, absFilePath, err)
+			}
+		}
+		if err = testCase.Render.validateKubeVersion(); err != nil {
+			return spec, fmt.Errorf("test case `%v` in %v: %w", testCase.Title, absFilePath, err)
+		}
+	}
+	if err = spec.checkSnapshotPaths(); err != nil {
+		return spec, err
+	}
 	return spec, err
 }
 
-func (s HelmSpec) Execute() (result SpecResult) {
+// executes all test cases of the spec using at most `settings.Jobs`
+// concurrent workers, results are reported in test case order
+func (s HelmSpec) Execute(ctx context.Context, settings TestRunSettings) (result SpecResult) {
+	dependencies := newDependencyCache(ctx, settings)
+	testCaseResults := make([]TestCaseResult, len(s.TestCases))
+	parallelize(len(s.TestCases), settings.Jobs, func(idx int) {
+		testCaseResults[idx] = s.executeTestCase(ctx, idx, settings, dependencies)
+	})
+	return s.collectResults(testCaseResults)
+}
+
+// returns the timeout of a test case, falling back to the timeout of the spec
+// and then to the run settings
+func (s HelmSpec) testCaseTimeout(testCase TestCase, settings TestRunSettings) time.Duration {
+	if testCase.timeout > 0 {
+		return testCase.timeout
+	}
+	if s.timeout > 0 {
+		return s.timeout
+	}
+	return settings.Timeout
+}
+
+// executes a single test case after making sure the chart dependencies are built
+// and compares the rendered manifest to its snapshot if requested.
+// Test cases are skipped once ctx is cancelled
+func (s HelmSpec) executeTestCase(ctx context.Context, idx int, settings TestRunSettings, dependencies *dependencyCache) TestCaseResult {
+	testCase := s.TestCases[idx]
+	if !settings.Filter.Matches(s.Title, testCase) || ctx.Err() != nil {
+		return testCase.skip()
+	}
+	timeout := s.testCaseTimeout(testCase, settings)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := dependencies.Build(ctx, s.ChartPath); err != nil {
+		return aborted(ctx, timeout, testCase.abort(err))
+	}
+	result := aborted(ctx, timeout, testCase.Execute(ctx, s.ChartPath, settings))
+	if testCase.Snapshot && !testCase.Render.ExpectsFailure() && result.Error == nil {
+		snapshot := matchSnapshot(s.snapshotPath(testCase), result.Manifest, settings.UpdateSnapshots)
+		result.Snapshot = &snapshot
+		result.Succeeded = result.Succeeded && snapshot.Succeeded
+	}
+	return result
+}
+
+// fails a test case that ran into its timeout or was interrupted,
+// even if rendering was expected to fail
+func aborted(ctx context.Context, timeout time.Duration, result TestCaseResult) TestCaseResult {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Succeeded = false
+		result.Error = fmt.Errorf("test case timed out after %v: %w", timeout, ctx.Err())
+	case errors.Is(ctx.Err(), context.Canceled):
+		result.Succeeded = false
+		result.Error = fmt.Errorf("test case interrupted: %w", ctx.Err())
+	}
+	return result
+}
+
+// aggregates the results of the spec's test cases into a spec result
+func (s HelmSpec) collectResults(testCaseResults []TestCaseResult) (result SpecResult) {
 	result.Title = s.Title
 	result.ChartPath = s.ChartPath
 	result.Succeeded = true
-	for _, c := range s.TestCases {
-		r := c.Execute(s.ChartPath)
+	for _, r := range testCaseResults {
 		result.Succeeded = result.Succeeded && r.Succeeded
 		result.TestCaseResults = append(result.TestCaseResults, r)
 	}
@@ -0,0 +1,60 @@
+// Code generated by gendocs; DO NOT EDIT.
+
+package helmspec
+
+// doc comments of the spec file types, keyed by type and field name.
+// The doc comment of the type itself has an empty field name
+var specDocs = map[string]map[string]string{
+	"HelmSpec": {
+		"":             "a related group of test cases for the same helm chart",
+		"ChartPath":    "path to the helm chart (absolute or relative to the spec file directory)",
+		"Defaults":     "render instructions inherited by every test case",
+		"FilePath":     "absolute path of the spec file the spec was loaded from",
+		"KubeVersions": "runs every test case once per kubernetes version, i.e. [\"1.24.0\", \"1.25.0\"].\nTest cases with a `kubeVersion`, set by themselves or by the defaults, run only once",
+		"TestCases":    "test cases to run for the helm chart",
+		"Timeout":      "maximum duration of each test case, i.e. `30s`, overrides the `--timeout` flag",
+		"Title":        "title",
+	},
+	"TestCase": {
+		"":           "a testcase bundles rendering instructions with a list of assertions\nto perform against the rendered output",
+		"Assertions": "assertions against the rendering output",
+		"Matrix":     "runs the test case once per combination of parameter values. `${name}` placeholders\nin the title, render instructions including those inherited from the defaults,\nqueries, document selectors and expected results are replaced with the values.\nUnquoted placeholders making up a whole value in yaml are replaced with the typed value",
+		"Parameters": "the parameter values of a test case expanded from a matrix",
+		"Render":     "inputs for rendering a helm chart with `helm template`",
+		"Snapshot":   "compare the whole rendered manifest against a snapshot stored next to the spec file",
+		"Tags":       "tags to select test cases with the `--tags` and `--exclude-tags` flags",
+		"Timeout":    "maximum duration of the test case, i.e. `30s`, overrides the timeout of the spec",
+		"Title":      "title of the testcase",
+	},
+	"RenderInstructions": {
+		"":                   "inputs for rendering a the chart with `helm template`",
+		"APIVersions":        "api versions added to `.Capabilities.APIVersions`, i.e. `monitoring.coreos.com/v1`",
+		"DefaultValues":      "the inline values of the spec defaults, layered after the values files of\nthe defaults and before the test case's own values files",
+		"ExpectedError":      "require rendering to fail with an error containing this substring\nor matching it as a regular expression, implies `shouldFailToRender`",
+		"ExtraArgs":          "extra arguments passed through to the helm CLI, i.e. [\"--set-file\", \"foo=foo.txt\"]",
+		"KubeVersion":        "kubernetes version used for `.Capabilities.KubeVersion`, i.e. `1.25.0`",
+		"Namespace":          "the release namespace to pass to `helm template`",
+		"ReleaseName":        "the release name to pass to `helm template`",
+		"ShouldFailToRender": "require rendering to fail for the test to pass",
+		"Values":             "all user-supplied values in one inline yaml document",
+		"ValuesFiles":        "values files (absolute or relative to the spec file directory), layered in order before `values`",
+	},
+	"Assertion": {
+		"":               "checks the output of a `yq` query against rendered manifests",
+		"Description":    "human-readable description of what the assertion tests",
+		"Document":       "restricts the query to the rendered documents matching the selector",
+		"ExpectedResult": "a string that the output of the `yq` query is compared to in order for the test to pass.\nA yaml map or list is compared semantically, ignoring key order, quoting and indentation",
+		"Operator":       "how the output of the `yq` query is compared to the expected result,\none of the AllowedOperators, defaults to `equals`",
+		"Query":          "a [yq] query to perform against the rendering output\nThe output will contain all rendered manifests with document separators,\nor only the documents matching `document` if set\n[yq]: https://mikefarah.gitbook.io/yq/",
+		"Structured":     "set if the expected result was given as a yaml map or list instead of a string",
+	},
+	"DocumentSelector": {
+		"":           "selects the rendered documents an assertion's query runs against.\nAll fields that are set must match",
+		"APIVersion": "the `apiVersion` of the document, i.e. `apps/v1`",
+		"Kind":       "the `kind` of the document, i.e. `Deployment`",
+		"Labels":     "labels the document must have in `metadata.labels`",
+		"Multiple":   "allow more than one document to match, by default exactly one document must match",
+		"Name":       "the `metadata.name` of the document",
+		"Template":   "the template the document was rendered from, relative to the chart directory\nlike `templates/service.yaml` or as in the `# Source:` comment",
+	},
+}
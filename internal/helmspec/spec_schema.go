@@ -0,0 +1,151 @@
+package helmspec
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:generate go run ./gendocs
+
+// json schema draft of the spec file schema, supported by the yaml language server
+const specSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// generates the json schema of spec files from the spec types and their doc comments
+type specSchemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+// returns the name of a field in spec files. Keys are matched case-insensitively,
+// fields without a json name are written in lower camel case like `expectedResult`
+func schemaPropertyName(field reflect.StructField) string {
+	name := fieldName(field)
+	if name != field.Name {
+		return name
+	}
+	first, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(first)) + name[size:]
+}
+
+// matches a property name case-insensitively like NewSpec matches keys, i.e.
+// `^[tT][iI][tT][lL][eE]$`. Editors do not support the `(?i)` flag
+func caseInsensitivePattern(name string) string {
+	pattern := &strings.Builder{}
+	pattern.WriteString("^")
+	for _, r := range name {
+		lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+		if lower == upper {
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+			continue
+		}
+		pattern.WriteString("[" + string(lower) + string(upper) + "]")
+	}
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
+// allowed values of fields, keyed by type and field name
+var specEnums = map[reflect.Type]map[string][]string{
+	reflect.TypeOf(Assertion{}): {"Operator": AllowedOperators[:]},
+}
+
+// returns the unquoted yaml 1.1 booleans, which editors parse as strings
+func yaml11BoolStrings() []interface{} {
+	names := []string{}
+	for name := range yaml11Bools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := []interface{}{}
+	for _, name := range names {
+		values = append(values, name)
+	}
+	return values
+}
+
+// like the spec validation, `null` is accepted for every field
+func (g *specSchemaGenerator) schemaFor(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		return g.schemaFor(t.Elem())
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"anyOf": []interface{}{
+			map[string]interface{}{"type": []interface{}{"boolean", "null"}},
+			map[string]interface{}{"enum": yaml11BoolStrings()},
+		}}
+	case reflect.String:
+		return map[string]interface{}{"type": []interface{}{"string", "null"}}
+	case reflect.Slice:
+		return map[string]interface{}{"type": []interface{}{"array", "null"}, "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": []interface{}{"object", "null"}, "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if _, ok := g.definitions[t.Name()]; !ok {
+			// register before recursing to support self-referencing types
+			g.definitions[t.Name()] = nil
+			g.definitions[t.Name()] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	default:
+		// i.e. matrix parameter values
+		return map[string]interface{}{}
+	}
+}
+
+// returns the schema of a spec type, unknown keys are not allowed. Keys are listed in
+// lower camel case for completion and accepted in any case like NewSpec accepts them
+func (g *specSchemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	patternProperties := map[string]interface{}{}
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if fieldName(field) == "" {
+			continue
+		}
+		property := map[string]interface{}{}
+		if !untypedFields[t][field.Name] {
+			property = g.schemaFor(field.Type)
+		}
+		if enum, ok := specEnums[t][field.Name]; ok {
+			values := []interface{}{nil}
+			for _, value := range enum {
+				values = append(values, value)
+			}
+			property["enum"] = values
+		}
+		if doc := specDocs[t.Name()][field.Name]; doc != "" {
+			if _, isRef := property["$ref"]; isRef {
+				// siblings of `$ref` are ignored by draft-07 validators
+				property = map[string]interface{}{"allOf": []interface{}{property}}
+			}
+			property["description"] = doc
+		}
+		properties[schemaPropertyName(field)] = property
+		patternProperties[caseInsensitivePattern(schemaPropertyName(field))] = property
+	}
+	s := map[string]interface{}{
+		"type":                 []interface{}{"object", "null"},
+		"properties":           properties,
+		"patternProperties":    patternProperties,
+		"additionalProperties": false,
+	}
+	if doc := specDocs[t.Name()][""]; doc != "" {
+		s["description"] = doc
+	}
+	return s
+}
+
+// returns the json schema of spec files, i.e. for validation and completion in editors
+func SpecSchema() ([]byte, error) {
+	g := specSchemaGenerator{definitions: map[string]interface{}{}}
+	root := g.schemaFor(reflect.TypeOf(HelmSpec{}))
+	root["$schema"] = specSchemaDraft
+	root["title"] = "helm-spec spec file"
+	root["definitions"] = g.definitions
+	return json.MarshalIndent(root, "", "  ")
+}
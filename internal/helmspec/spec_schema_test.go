@@ -0,0 +1,95 @@
+package helmspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func compileSpecSchema(t *testing.T) *jsonschema.Schema {
+	t.Helper()
+	content, err := SpecSchema()
+	assert.NoError(t, err)
+	compiler := jsonschema.NewCompiler()
+	assert.NoError(t, compiler.AddResource("spec.json", bytes.NewReader(content)))
+	schema, err := compiler.Compile("spec.json")
+	assert.NoError(t, err)
+	return schema
+}
+
+// decodes a spec file the way the yaml language server passes it to the schema
+func specDocument(t *testing.T, content []byte) interface{} {
+	t.Helper()
+	jsonContent, err := yaml.YAMLToJSON(content)
+	assert.NoError(t, err)
+	var document interface{}
+	assert.NoError(t, json.Unmarshal(jsonContent, &document))
+	return document
+}
+
+func TestSpecSchemaAcceptsSpecFiles(t *testing.T) {
+	schema := compileSpecSchema(t)
+	for _, specFile := range []string{
+		"./testdata/charts/example/specs/example_spec.yaml",
+		"./testdata/charts/example/specs/successful_spec.yaml",
+		"./testdata/charts/example/specs/operators_spec.yaml",
+		"./testdata/charts/example/specs/defaults_spec.yaml",
+		"./testdata/charts/example/specs/values_files_spec.yaml",
+		"./testdata/charts/example/specs/matrix_spec.yaml",
+		"./testdata/charts/required/specs/expected_error_spec.yaml",
+	} {
+		content, err := os.ReadFile(specFile)
+		assert.NoError(t, err)
+		assert.NoError(t, schema.Validate(specDocument(t, content)), specFile)
+	}
+}
+
+func TestSpecSchemaRejectsInvalidSpecFiles(t *testing.T) {
+	schema := compileSpecSchema(t)
+	for _, content := range []string{
+		"testCases:\n- title: foo\n  render:\n    shouldFailToRendr: true\n",
+		"testCases:\n- assertions:\n  - query: .kind\n    expectedResults: Service\n",
+		"testCases:\n- assertions:\n  - query: .kind\n    operator: equal\n",
+		"testCases:\n- assertions:\n  - document:\n      kinds: [Service]\n",
+		"kubeVersions: 1.25.0\n",
+	} {
+		assert.Error(t, schema.Validate(specDocument(t, []byte(content))), content)
+	}
+}
+
+func TestSpecSchemaDescribesFields(t *testing.T) {
+	content, err := SpecSchema()
+	assert.NoError(t, err)
+	schema := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(content, &schema))
+	testCase := schema["definitions"].(map[string]interface{})["TestCase"].(map[string]interface{})
+	assert.Contains(t, testCase["description"], "a testcase bundles rendering instructions")
+	properties := testCase["properties"].(map[string]interface{})
+	assert.Equal(t, "title of the testcase", properties["title"].(map[string]interface{})["description"])
+	assert.NotContains(t, properties, "parameters")
+	assertion := schema["definitions"].(map[string]interface{})["Assertion"].(map[string]interface{})
+	assert.NotContains(t, assertion["properties"], "structured")
+	assert.Contains(t, assertion["properties"], "expectedResult")
+}
+
+func TestSpecSchemaAgreesWithSpecValidation(t *testing.T) {
+	schema := compileSpecSchema(t)
+	for content, valid := range map[string]bool{
+		"Title: foo\ntestCases:\n- TITLE: bar\n  Snapshot: yes\n":                                   true,
+		"testCases:\n- title: foo\n  render:\n    shouldFailToRender: ON\n":                         true,
+		"testCases:\n- title: foo\n  tags: ~\n  assertions:\n  - query: .kind\n    operator: ~\n":   true,
+		"testCases:\n- title: foo\n  snapshot: maybe\n":                                             false,
+		"testCases:\n- title: foo\n  assertions:\n  - query: .kind\n    operator: equal\n":          false,
+		"testCases:\n- title: foo\n  assertions:\n  - query: .kind\n    Expected_Result: Service\n": false,
+	} {
+		problems, err := ValidateSpecFile(writeSpecFile(t, content))
+		assert.NoError(t, err)
+		assert.Equal(t, valid, len(problems) == 0, "%v: %v", content, problems)
+		assert.Equal(t, valid, schema.Validate(specDocument(t, []byte(content))) == nil, content)
+	}
+}
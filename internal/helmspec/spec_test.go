@@ -1,9 +1,12 @@
 package helmspec
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,7 +28,7 @@ func TestChartPathIsRelativeToSpecFile(t *testing.T) {
 func TestExecuteTestCaseHappyPath(t *testing.T) {
 	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.TestCases[0].Execute(spec.ChartPath)
+	result := spec.TestCases[0].Execute(context.Background(), spec.ChartPath, TestRunSettings{})
 	assert.NoError(t, result.Error)
 	assert.Equal(t, spec.TestCases[0].Title, result.Title)
 	assert.Equal(t, spec.TestCases[0].Render, result.Render)
@@ -40,7 +43,7 @@ func TestExecuteTestCaseHappyPath(t *testing.T) {
 func TestExecuteTestDoesNotSucceedIfAnyAssertionFails(t *testing.T) {
 	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.TestCases[1].Execute(spec.ChartPath)
+	result := spec.TestCases[1].Execute(context.Background(), spec.ChartPath, TestRunSettings{})
 	assert.NoError(t, result.Error)
 	assert.False(t, result.Succeeded)
 }
@@ -48,9 +51,11 @@ func TestExecuteTestDoesNotSucceedIfAnyAssertionFails(t *testing.T) {
 func TestExecuteTestShouldAbortWhenRenderingFailsUnexpectedly(t *testing.T) {
 	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.TestCases[0].Execute("./not/an/existing/chart")
+	result := spec.TestCases[0].Execute(context.Background(), "./not/an/existing/chart", TestRunSettings{})
 	assert.Error(t, result.Error)
 	assert.False(t, result.Succeeded)
+	assert.Contains(t, result.Stderr, "not/an/existing/chart")
+	assert.Contains(t, result.Command, "helm template foo ./not/an/existing/chart -n default")
 	// should not run or report assertions if we have an error
 	// at the test case level
 	assert.Equal(t, 0, len(result.AssertionResults))
@@ -59,7 +64,7 @@ func TestExecuteTestShouldAbortWhenRenderingFailsUnexpectedly(t *testing.T) {
 func TestExecuteTestShouldSucceedOnExpectedFailure(t *testing.T) {
 	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.TestCases[2].Execute("./not/an/existing/chart")
+	result := spec.TestCases[2].Execute(context.Background(), "./not/an/existing/chart", TestRunSettings{})
 	assert.True(t, result.Succeeded)
 	// should not run or report assertions if we have an error
 	// at the test case level
@@ -69,13 +74,262 @@ func TestExecuteTestShouldSucceedOnExpectedFailure(t *testing.T) {
 func TestSpecResultShouldNotSucceedIfAnyTestCaseFails(t *testing.T) {
 	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.Execute()
+	result := spec.Execute(context.Background(), TestRunSettings{})
 	assert.False(t, result.Succeeded)
 }
 
 func TestSpecResultShouldSucceedIfAllTestCasesSucceed(t *testing.T) {
-	spec, err := NewSpec("./testdata/charts/example/specs/successful_spec.yaml")
+	for _, specFile := range []string{
+		"./testdata/charts/example/specs/successful_spec.yaml",
+		"./testdata/charts/example/specs/operators_spec.yaml",
+		"./testdata/charts/example/specs/defaults_spec.yaml",
+		"./testdata/charts/example/specs/values_files_spec.yaml",
+		"./testdata/charts/example/specs/matrix_spec.yaml",
+		"./testdata/charts/required/specs/expected_error_spec.yaml",
+	} {
+		spec, err := NewSpec(specFile)
+		assert.NoError(t, err)
+		result := spec.Execute(context.Background(), TestRunSettings{})
+		assert.True(t, result.Succeeded, specFile)
+	}
+}
+
+func TestValuesFilesAreRelativeToSpecFile(t *testing.T) {
+	expectedValuesFile, err := filepath.Abs("./testdata/charts/example/ci/ci-values.yaml")
+	assert.NoError(t, err)
+	spec, err := NewSpec("./testdata/charts/example/specs/values_files_spec.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{expectedValuesFile}, spec.TestCases[0].Render.ValuesFiles)
+}
+
+func TestMissingValuesFilesFailToLoad(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "missing_values_spec.yaml")
+	content := `
+title: missing values file
+chartPath: ../charts/example
+testCases:
+- title: missing values file
+  render:
+    valuesFiles:
+    - ./does-not-exist.yaml
+`
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	_, err := NewSpec(specFile)
+	assert.ErrorContains(t, err, "test case `missing values file`")
+	assert.ErrorContains(t, err, "values file `./does-not-exist.yaml` does not seem to exist")
+}
+
+func TestTestCasesInheritDefaults(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/defaults_spec.yaml")
+	assert.NoError(t, err)
+	for _, c := range spec.TestCases {
+		assert.Equal(t, "foo", c.Render.ReleaseName)
+		assert.Equal(t, "default", c.Render.Namespace)
+		assert.Contains(t, c.Render.DefaultValues, "repository: test")
+	}
+}
+
+func TestExpectedErrorMatchesHelmStderr(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/required/specs/expected_error_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.Execute()
+	testCase := spec.TestCases[0]
+	result := testCase.Execute(context.Background(), spec.ChartPath, TestRunSettings{})
 	assert.True(t, result.Succeeded)
+	assert.Contains(t, RenderErrorMessage(result.Error), "service.port is required")
+
+	testCase.Render.ExpectedError = `service\.port is (required|mandatory)`
+	result = testCase.Execute(context.Background(), spec.ChartPath, TestRunSettings{})
+	assert.True(t, result.Succeeded)
+
+	testCase.Render.ExpectedError = "service.type is required"
+	result = testCase.Execute(context.Background(), spec.ChartPath, TestRunSettings{})
+	assert.False(t, result.Succeeded)
+}
+
+func TestExpectedErrorDoesNotMatchUnrelatedFailures(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/required/specs/expected_error_spec.yaml")
+	assert.NoError(t, err)
+	testCase := spec.TestCases[0]
+	result := testCase.Execute(context.Background(), "./not/an/existing/chart", TestRunSettings{})
+	assert.Error(t, result.Error)
+	assert.False(t, result.Succeeded)
+	var renderErr *RenderError
+	assert.ErrorAs(t, result.Error, &renderErr)
+	assert.Contains(t, renderErr.Stderr, "not/an/existing/chart")
+}
+
+// blocks until the context of the test case is done
+type blockingRenderer struct{}
+
+func (blockingRenderer) Render(ctx context.Context, _ RenderInstructions, _ string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestTestCasesFailWhenTimingOut(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
+	assert.NoError(t, err)
+	settings := TestRunSettings{Renderer: blockingRenderer{}, SkipDependencyBuild: true, Timeout: 10 * time.Millisecond}
+	result := spec.Execute(context.Background(), settings)
+	assert.False(t, result.Succeeded)
+	for _, r := range result.TestCaseResults {
+		// even test cases expecting rendering to fail
+		assert.False(t, r.Succeeded)
+		assert.ErrorContains(t, r.Error, "test case timed out after 10ms")
+	}
+}
+
+func TestTestCaseTimeoutOverridesSpecAndSettings(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "timeout_spec.yaml")
+	content := "title: timeout\ntimeout: 1m\ntestCases:\n- title: slow\n  timeout: 5m\n- title: default\n"
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	spec, err := NewSpec(specFile)
+	assert.NoError(t, err)
+	settings := TestRunSettings{Timeout: time.Second}
+	assert.Equal(t, 5*time.Minute, spec.testCaseTimeout(spec.TestCases[0], settings))
+	assert.Equal(t, time.Minute, spec.testCaseTimeout(spec.TestCases[1], settings))
+	spec.timeout = 0
+	assert.Equal(t, time.Second, spec.testCaseTimeout(spec.TestCases[1], settings))
+
+	assert.NoError(t, os.WriteFile(specFile, []byte("title: timeout\ntimeout: soon\n"), 0o644))
+	_, err = NewSpec(specFile)
+	assert.ErrorContains(t, err, "invalid timeout")
+
+	assert.NoError(t, os.WriteFile(specFile, []byte("title: timeout\ntestCases:\n- title: slow\n  timeout: soon\n"), 0o644))
+	_, err = NewSpec(specFile)
+	assert.ErrorContains(t, err, "test case `slow`")
+	assert.ErrorContains(t, err, "invalid timeout")
+}
+
+func TestScalarExpectedResultsKeepTheirSpecText(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "scalar_spec.yaml")
+	content := `
+title: scalars
+testCases:
+- title: versions
+  assertions:
+  - query: .version
+    expectedResult: 1.10
+  - query: .enabled
+    expectedResult: yes
+  - query: .labels
+    expectedResult: {version: 1.10}
+  - query: .empty
+    expectedResult:
+`
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	spec, err := NewSpec(specFile)
+	assert.NoError(t, err)
+	assertions := spec.TestCases[0].Assertions
+	assert.Equal(t, "1.10", assertions[0].ExpectedResult)
+	assert.Equal(t, "yes", assertions[1].ExpectedResult)
+	assert.True(t, assertions[2].Structured)
+	assert.Equal(t, "", assertions[3].ExpectedResult)
+
+	manifest := "version: 1.10\nenabled: yes\n"
+	assert.True(t, assertions[0].Evaluate(manifest).Succeeded)
+	assert.True(t, assertions[1].Evaluate(manifest).Succeeded)
+}
+
+func TestInterruptedTestCasesFail(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/example_spec.yaml")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	settings := TestRunSettings{Renderer: blockingRenderer{}, SkipDependencyBuild: true}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	result := spec.Execute(ctx, settings)
+	assert.False(t, result.Succeeded)
+	interrupted := 0
+	for _, r := range result.TestCaseResults {
+		if r.Skipped {
+			continue
+		}
+		// cases expecting rendering to fail must not pass because helm was killed
+		assert.False(t, r.Succeeded)
+		assert.ErrorContains(t, r.Error, "test case interrupted")
+		interrupted++
+	}
+	assert.Greater(t, interrupted, 0)
+}
+
+func TestSpecKubeVersionsRunEachTestCasePerVersion(t *testing.T) {
+	chartPath, err := filepath.Abs("./testdata/charts/example")
+	assert.NoError(t, err)
+	specFile := filepath.Join(t.TempDir(), "kube_versions_spec.yaml")
+	content := `
+title: kube versions
+chartPath: ` + chartPath + `
+kubeVersions: ["1.18.0", "1.25.0"]
+defaults:
+  values: |
+    ingress:
+      enabled: true
+testCases:
+- title: ingress api version
+  assertions:
+  - query: select(.kind == "Ingress") | .apiVersion
+    expectedResult: networking.k8s.io/v1
+- title: pinned to ${kubeVersion}
+  render:
+    kubeVersion: 1.25.0
+`
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	spec, err := NewSpec(specFile)
+	assert.NoError(t, err)
+	titles := []string{}
+	for _, c := range spec.TestCases {
+		titles = append(titles, c.Title)
+	}
+	assert.Equal(t, []string{
+		"ingress api version [kubeVersion=1.18.0]",
+		"ingress api version [kubeVersion=1.25.0]",
+		"pinned to ${kubeVersion}",
+	}, titles)
+	result := spec.Execute(context.Background(), TestRunSettings{})
+	assert.False(t, result.TestCaseResults[0].Succeeded)
+	assert.Equal(t, "1.18.0", result.TestCaseResults[0].Render.KubeVersion)
+	assert.Equal(t, map[string]string{"kubeVersion": "1.18.0"}, result.TestCaseResults[0].Parameters)
+	assert.True(t, result.TestCaseResults[1].Succeeded)
+	assert.True(t, result.TestCaseResults[2].Succeeded)
+}
+
+func TestDefaultKubeVersionTakesPrecedenceOverSpecKubeVersions(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "kube_versions_spec.yaml")
+	content := `
+title: kube versions
+kubeVersions: ["1.18.0", "1.25.0"]
+defaults:
+  kubeVersion: 1.24.0
+testCases:
+- title: foo
+`
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	spec, err := NewSpec(specFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(spec.TestCases))
+	assert.Equal(t, "foo", spec.TestCases[0].Title)
+	assert.Equal(t, "1.24.0", spec.TestCases[0].Render.KubeVersion)
+}
+
+func TestInvalidKubeVersionFailsToLoad(t *testing.T) {
+	specFile := filepath.Join(t.TempDir(), "kube_versions_spec.yaml")
+	content := "title: kube versions\nkubeVersions: [latest]\ntestCases:\n- title: foo\n"
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	_, err := NewSpec(specFile)
+	assert.ErrorContains(t, err, "test case `foo [kubeVersion=latest]`")
+	assert.ErrorContains(t, err, "invalid kube version `latest`")
+}
+
+func TestDependencyBuildFailuresFailExpectedFailures(t *testing.T) {
+	spec := HelmSpec{
+		ChartPath: "./testdata/charts/example",
+		TestCases: []TestCase{{Title: "fails", Render: RenderInstructions{ShouldFailToRender: true}}},
+	}
+	dependencies := newDependencyCache(context.Background(), TestRunSettings{})
+	dependencies.build = func(_ context.Context, _ string) error {
+		return errors.New("failed to build dependencies")
+	}
+	result := spec.executeTestCase(context.Background(), 0, TestRunSettings{}, dependencies)
+	assert.False(t, result.Succeeded)
+	assert.ErrorContains(t, result.Error, "failed to build dependencies")
 }
@@ -0,0 +1,336 @@
+package helmspec
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mikefarah/yq/v4/pkg/yqlib"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// a problem in a spec file found by ValidateSpecFile
+type SpecProblem struct {
+	// path of the spec file
+	File string `json:"file"`
+	// line of the problem, 0 if the problem does not belong to a line
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+func (p SpecProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%v: %v", p.File, p.Message)
+	}
+	return fmt.Sprintf("%v:%v: %v", p.File, p.Line, p.Message)
+}
+
+// returned instead of running spec files with problems
+type InvalidSpecsError struct {
+	Problems []SpecProblem
+}
+
+func (e *InvalidSpecsError) Error() string {
+	lines := []string{fmt.Sprintf("found %v problems in spec files:", len(e.Problems))}
+	for _, p := range e.Problems {
+		lines = append(lines, p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validates all spec files, returns an InvalidSpecsError if any spec file has problems
+func ValidateSpecFiles(specFiles []string) error {
+	_, err := LoadSpecs(specFiles)
+	return err
+}
+
+// yaml.v3 syntax errors start with the line, i.e. `yaml: line 3: mapping values are not allowed`
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): `)
+
+// strictly checks a spec file for unknown keys, values of the wrong type, duplicate
+// test case titles, empty assertion lists and yq queries that do not compile.
+// Problems that cannot be found that way are reported by loading the spec
+func ValidateSpecFile(filePath string) (problems []SpecProblem, err error) {
+	_, problems, err = loadSpecFile(filePath)
+	return problems, err
+}
+
+// validates a spec file and loads it if it has no problems
+func loadSpecFile(filePath string) (spec *HelmSpec, problems []SpecProblem, err error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := specValidator{file: filePath}
+	root := yamlv3.Node{}
+	if err := yamlv3.Unmarshal(content, &root); err != nil {
+		line := 0
+		message := err.Error()
+		if match := yamlErrorLine.FindStringSubmatch(message); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			message = strings.TrimPrefix(message, match[0])
+		}
+		v.report(line, "invalid yaml: %v", message)
+		return nil, v.problems, nil
+	}
+	if len(root.Content) > 0 {
+		v.checkDocument(resolveAlias(root.Content[0]))
+	}
+	// i.e. invalid timeouts or missing values files
+	if len(v.problems) > 0 {
+		return nil, v.problems, nil
+	}
+	if spec, err = NewSpec(filePath); err != nil {
+		v.report(0, "%v", err)
+		return nil, v.problems, nil
+	}
+	return spec, nil, nil
+}
+
+// checks the parsed yaml document of a spec file
+func (v *specValidator) checkDocument(document *yamlv3.Node) {
+	v.checkTypes(document, reflect.TypeOf(HelmSpec{}))
+	if testCases := mappingValue(document, "testCases"); testCases != nil && testCases.Kind == yamlv3.SequenceNode {
+		// spec-level kube versions are added to the matrix of every test case
+		implicitParameters := []string{}
+		if mappingValue(document, "kubeVersions") != nil {
+			implicitParameters = append(implicitParameters, kubeVersionParameter)
+		}
+		v.checkTestCases(testCases, implicitParameters)
+	}
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		return v.problems[i].Line < v.problems[j].Line
+	})
+}
+
+// collects the problems of a single spec file
+type specValidator struct {
+	file     string
+	problems []SpecProblem
+}
+
+func (v *specValidator) report(line int, format string, args ...interface{}) {
+	v.problems = append(v.problems, SpecProblem{File: v.file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func resolveAlias(node *yamlv3.Node) *yamlv3.Node {
+	for node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// returns the value of a key of a mapping node, keys are matched case-insensitively
+// like NewSpec matches them to struct fields
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		if strings.EqualFold(node.Content[idx].Value, key) {
+			return resolveAlias(node.Content[idx+1])
+		}
+	}
+	return nil
+}
+
+// returns the name of a struct field in spec files, "" for fields that cannot be set
+func fieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// fields that accept any yaml value
+var untypedFields = map[reflect.Type]map[string]bool{
+	reflect.TypeOf(Assertion{}): {"ExpectedResult": true},
+}
+
+// NewSpec parses yaml 1.1, where these unquoted strings are booleans as well
+var yaml11Bools = map[string]bool{
+	"y": true, "Y": true, "yes": true, "Yes": true, "YES": true, "on": true, "On": true, "ON": true,
+	"n": false, "N": false, "no": false, "No": false, "NO": false, "off": false, "Off": false, "OFF": false,
+}
+
+// returns the value of a boolean scalar the way NewSpec parses it
+func parseBool(node *yamlv3.Node) (value bool, ok bool) {
+	if node == nil || node.Kind != yamlv3.ScalarNode {
+		return false, false
+	}
+	if node.Tag == "!!bool" {
+		value, err := strconv.ParseBool(node.Value)
+		return value, err == nil
+	}
+	if node.Style == 0 {
+		value, ok = yaml11Bools[node.Value]
+	}
+	return value, ok
+}
+
+// reports unknown and duplicate keys and values of the wrong type
+func (v *specValidator) checkTypes(node *yamlv3.Node, t reflect.Type) {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// `null` is accepted for every field
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			v.report(node.Line, "expected a map, got %v", describeNode(node))
+			return
+		}
+		seen := map[string]bool{}
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			key := node.Content[idx]
+			if seen[strings.ToLower(key.Value)] {
+				v.report(key.Line, "duplicate key `%v`", key.Value)
+				continue
+			}
+			seen[strings.ToLower(key.Value)] = true
+			field, ok := findField(t, key.Value)
+			if !ok {
+				v.report(key.Line, "unknown key `%v`", key.Value)
+				continue
+			}
+			if untypedFields[t][field.Name] {
+				continue
+			}
+			v.checkTypes(node.Content[idx+1], field.Type)
+			v.checkEnum(resolveAlias(node.Content[idx+1]), specEnums[t][field.Name])
+		}
+	case reflect.Slice:
+		if node.Kind != yamlv3.SequenceNode {
+			v.report(node.Line, "expected a list, got %v", describeNode(node))
+			return
+		}
+		for _, item := range node.Content {
+			v.checkTypes(item, t.Elem())
+		}
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			v.report(node.Line, "expected a map, got %v", describeNode(node))
+			return
+		}
+		for idx := 1; idx < len(node.Content); idx += 2 {
+			v.checkTypes(node.Content[idx], t.Elem())
+		}
+	case reflect.String:
+		// NewSpec does not convert numbers and booleans to strings
+		if node.Kind != yamlv3.ScalarNode || (node.Tag != "!!str" && node.Tag != "!!timestamp") {
+			v.report(node.Line, "expected a string, got %v", describeNode(node))
+		}
+	case reflect.Bool:
+		if _, ok := parseBool(node); !ok {
+			v.report(node.Line, "expected a boolean, got %v", describeNode(node))
+		}
+	case reflect.Interface:
+		// i.e. matrix parameter values
+	}
+}
+
+// reports strings that are not one of the allowed values of a field
+func (v *specValidator) checkEnum(node *yamlv3.Node, allowed []string) {
+	if len(allowed) == 0 || node.Kind != yamlv3.ScalarNode || node.Tag != "!!str" {
+		return
+	}
+	for _, value := range allowed {
+		if node.Value == value {
+			return
+		}
+	}
+	v.report(node.Line, "invalid value `%v`, must be one of `%v`", node.Value, allowed)
+}
+
+// returns the struct field a key of a spec file sets
+func findField(t reflect.Type, key string) (field reflect.StructField, ok bool) {
+	for idx := 0; idx < t.NumField(); idx++ {
+		field = t.Field(idx)
+		name := fieldName(field)
+		if name != "" && strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return field, false
+}
+
+// describes a yaml value for type errors, i.e. `a list` or `"foo"`
+func describeNode(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return "a map"
+	case yamlv3.SequenceNode:
+		return "a list"
+	case yamlv3.ScalarNode:
+		if node.Tag == "!!str" {
+			return strconv.Quote(node.Value)
+		}
+		return node.Value
+	default:
+		return strconv.Quote(node.Value)
+	}
+}
+
+// returns the matrix parameter names of a test case
+func matrixParameters(testCase *yamlv3.Node, implicitParameters []string) map[string]string {
+	parameters := map[string]string{}
+	for _, name := range implicitParameters {
+		parameters[name] = ""
+	}
+	if matrix := mappingValue(testCase, "matrix"); matrix != nil && matrix.Kind == yamlv3.MappingNode {
+		for idx := 0; idx < len(matrix.Content); idx += 2 {
+			parameters[matrix.Content[idx].Value] = ""
+		}
+	}
+	return parameters
+}
+
+// reports duplicate test case titles, empty assertion lists and invalid queries.
+// Queries with matrix placeholders are only complete after expanding the matrix
+// and are not checked
+func (v *specValidator) checkTestCases(testCases *yamlv3.Node, implicitParameters []string) {
+	titles := map[string]int{}
+	for _, testCase := range testCases.Content {
+		testCase = resolveAlias(testCase)
+		title := mappingValue(testCase, "title")
+		if title != nil && title.Kind == yamlv3.ScalarNode {
+			if line, ok := titles[title.Value]; ok {
+				v.report(title.Line, "duplicate test case title `%v`, first used in line %v", title.Value, line)
+			} else {
+				titles[title.Value] = title.Line
+			}
+		}
+		parameters := matrixParameters(testCase, implicitParameters)
+		assertions := mappingValue(testCase, "assertions")
+		if assertions == nil || assertions.Kind != yamlv3.SequenceNode {
+			continue
+		}
+		if len(assertions.Content) == 0 {
+			v.report(assertions.Line, "empty assertions list")
+		}
+		for _, assertion := range assertions.Content {
+			query := mappingValue(resolveAlias(assertion), "query")
+			if query == nil || query.Kind != yamlv3.ScalarNode || containsPlaceholder(query.Value, parameters) {
+				continue
+			}
+			if _, err := yqlib.ExpressionParser.ParseExpression(query.Value); err != nil {
+				v.report(query.Line, "invalid query `%v`: %v", query.Value, err)
+			}
+		}
+	}
+}
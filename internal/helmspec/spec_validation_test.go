@@ -0,0 +1,135 @@
+package helmspec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const invalidSpec = `title: invalid
+chartPath: ..
+testCases:
+- title: typos
+  render:
+    values:
+      image:
+        tag: 1.2.3
+    shouldFailToRendr: true
+  assertions:
+  - query: 'select(.kind == "Deployment"'
+    expectedResults: foo
+    expectedResult: {a: 1}
+- title: typos
+  snapshot: maybe
+  assertions: []
+- title: matrix
+  Tags: [a]
+  matrix:
+    type: ClusterIP
+`
+
+// writes a spec file to a temporary directory, returns its path
+func writeSpecFile(t *testing.T, content string) string {
+	t.Helper()
+	specFile := filepath.Join(t.TempDir(), "validate_spec.yaml")
+	assert.NoError(t, os.WriteFile(specFile, []byte(content), 0o644))
+	return specFile
+}
+
+func TestValidateSpecFileReportsProblemsWithLines(t *testing.T) {
+	specFile := writeSpecFile(t, invalidSpec)
+	problems, err := ValidateSpecFile(specFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []SpecProblem{
+		{File: specFile, Line: 7, Message: "expected a string, got a map"},
+		{File: specFile, Line: 9, Message: "unknown key `shouldFailToRendr`"},
+		{File: specFile, Line: 11, Message: "invalid query `select(.kind == \"Deployment\"`: bad expression - probably missing close bracket on SELECT"},
+		{File: specFile, Line: 12, Message: "unknown key `expectedResults`"},
+		{File: specFile, Line: 14, Message: "duplicate test case title `typos`, first used in line 4"},
+		{File: specFile, Line: 15, Message: "expected a boolean, got \"maybe\""},
+		{File: specFile, Line: 16, Message: "empty assertions list"},
+		{File: specFile, Line: 20, Message: "expected a list, got \"ClusterIP\""},
+	}, problems)
+	assert.Equal(t, specFile+":9: unknown key `shouldFailToRendr`", problems[1].String())
+}
+
+func TestValidateSpecFileAcceptsValidSpecs(t *testing.T) {
+	for _, specFile := range []string{
+		"./testdata/charts/example/specs/example_spec.yaml",
+		"./testdata/charts/example/specs/successful_spec.yaml",
+		"./testdata/charts/example/specs/operators_spec.yaml",
+		"./testdata/charts/example/specs/defaults_spec.yaml",
+		"./testdata/charts/example/specs/values_files_spec.yaml",
+		"./testdata/charts/example/specs/matrix_spec.yaml",
+		"./testdata/charts/required/specs/expected_error_spec.yaml",
+	} {
+		problems, err := ValidateSpecFile(specFile)
+		assert.NoError(t, err)
+		assert.Empty(t, problems, specFile)
+	}
+}
+
+func TestValidateSpecFileReportsInvalidYaml(t *testing.T) {
+	specFile := writeSpecFile(t, "title: foo\ntestCases:\n- title: bar\n  render: [\n")
+	problems, err := ValidateSpecFile(specFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, 4, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "invalid yaml")
+}
+
+func TestValidateSpecFileReportsDuplicateKeys(t *testing.T) {
+	specFile := writeSpecFile(t, "title: foo\nTitle: bar\n")
+	problems, err := ValidateSpecFile(specFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []SpecProblem{{File: specFile, Line: 2, Message: "duplicate key `Title`"}}, problems)
+}
+
+func TestValidateSpecFileReportsLoadErrors(t *testing.T) {
+	specFile := writeSpecFile(t, "title: foo\ntimeout: soon\n")
+	problems, err := ValidateSpecFile(specFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, 0, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "invalid timeout")
+}
+
+func TestValidateSpecFileAcceptsTestCasesWithoutAssertions(t *testing.T) {
+	// i.e. checking that the chart renders without error
+	specFile := writeSpecFile(t, "title: foo\ntestCases:\n- title: renders\n  render:\n    releaseName: foo\n")
+	problems, err := ValidateSpecFile(specFile)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+}
+
+func TestValidateSpecFileAcceptsMatrixPlaceholdersInQueries(t *testing.T) {
+	specFile := writeSpecFile(t, `title: foo
+kubeVersions: ["1.25.0"]
+testCases:
+- title: ports
+  matrix:
+    f: [port, targetPort]
+  assertions:
+  - query: select(.kind == "Service") | .spec.ports[0].${f}
+    expectedResult: "80"
+  - query: .metadata.labels["${kubeVersion}"]
+    operator: isNull
+  - query: .spec.${undeclared}
+`)
+	problems, err := ValidateSpecFile(specFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, 12, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "invalid query `.spec.${undeclared}`")
+}
+
+func TestLoadSpecsRefusesInvalidSpecs(t *testing.T) {
+	specFile := writeSpecFile(t, invalidSpec)
+	_, err := LoadSpecs([]string{"./testdata/charts/example/specs/example_spec.yaml", specFile})
+	var invalid *InvalidSpecsError
+	assert.ErrorAs(t, err, &invalid)
+	assert.Equal(t, 8, len(invalid.Problems))
+	assert.Contains(t, err.Error(), "found 8 problems in spec files:\n"+specFile+":7: expected a string, got a map")
+}
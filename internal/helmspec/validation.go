@@ -0,0 +1,217 @@
+package helmspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// a rendered document that does not match the schema of its kind
+type SchemaViolation struct {
+	// kind and name of the invalid document, i.e. `Deployment/foo`
+	Document string `json:"document"`
+	// the chart template the document was rendered from
+	Source string `json:"source"`
+	// json pointer to the invalid field, i.e. `/spec/replicas`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (v SchemaViolation) String() string {
+	return fmt.Sprintf("%v at %v: %v", v.Document, v.Path, v.Message)
+}
+
+// validates rendered documents against kubernetes json schemas. Schemas are
+// looked up in a local directory in the layout of the kubernetes-json-schema
+// project (`<dir>/v<version>-standalone-strict/deployment-apps-v1.json` or
+// `<dir>/deployment-apps-v1.json`) and fall back to type schemas generated from
+// the API types helm-spec was built with if the kubernetes version matches
+// TypeSchemaKubeVersion. Type schemas only check field names and value types.
+// Kinds without a schema, i.e. custom resources, are not validated
+type SchemaValidator struct {
+	kubeVersion string
+	schemaDir   string
+	// fall back to type schemas for kinds missing in the schema directory
+	useTypeSchemas bool
+
+	mu          sync.Mutex
+	typeSchemas map[string][]byte
+	schemas     map[string]*jsonschema.Schema
+}
+
+func NewSchemaValidator(kubeVersion string, schemaDir string) (*SchemaValidator, error) {
+	if kubeVersion == "" {
+		kubeVersion = TypeSchemaKubeVersion
+	}
+	requested, err := chartutil.ParseKubeVersion(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kube version `%v`: %w", kubeVersion, err)
+	}
+	typeSchemaVersion, err := chartutil.ParseKubeVersion(TypeSchemaKubeVersion)
+	if err != nil {
+		return nil, err
+	}
+	useTypeSchemas := requested.Major == typeSchemaVersion.Major && requested.Minor == typeSchemaVersion.Minor
+	if !useTypeSchemas && schemaDir == "" {
+		return nil, fmt.Errorf("no type schemas for kubernetes %v, type schemas are for %v. Use a local schema directory instead", kubeVersion, TypeSchemaKubeVersion)
+	}
+	if schemaDir != "" {
+		info, err := os.Stat(schemaDir)
+		if err != nil {
+			return nil, fmt.Errorf("schema directory `%v` does not seem to exist: %w", schemaDir, err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("schema directory `%v` is not a directory", schemaDir)
+		}
+	}
+	return &SchemaValidator{
+		kubeVersion:    requested.Version,
+		schemaDir:      schemaDir,
+		useTypeSchemas: useTypeSchemas,
+		schemas:        map[string]*jsonschema.Schema{},
+	}, nil
+}
+
+// reads the schema for a kind from the schema directory or the type schemas,
+// returning nil if there is no schema for the kind
+func (v *SchemaValidator) loadSchema(fileName string) (content []byte, url string, err error) {
+	if v.schemaDir != "" {
+		candidates := []string{
+			filepath.Join(v.schemaDir, v.kubeVersion+"-standalone-strict", fileName),
+			filepath.Join(v.schemaDir, fileName),
+		}
+		for _, path := range candidates {
+			content, err = os.ReadFile(path)
+			if err == nil {
+				return content, path, nil
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, "", err
+			}
+		}
+	}
+	if !v.useTypeSchemas {
+		return nil, "", nil
+	}
+	if v.typeSchemas == nil {
+		if v.typeSchemas, err = typeSchemas(); err != nil {
+			return nil, "", err
+		}
+	}
+	return v.typeSchemas[fileName], "types:///" + fileName, nil
+}
+
+// returns the compiled schema for a kind or nil if there is none
+func (v *SchemaValidator) schema(gvk schema.GroupVersionKind) (*jsonschema.Schema, error) {
+	fileName := schemaFileName(gvk)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok := v.schemas[fileName]; ok {
+		return s, nil
+	}
+	content, url, err := v.loadSchema(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema for %v: %w", gvk, err)
+	}
+	var compiled *jsonschema.Schema
+	if content != nil {
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(url, bytes.NewReader(content)); err != nil {
+			return nil, fmt.Errorf("failed to load schema %v: %w", url, err)
+		}
+		if compiled, err = compiler.Compile(url); err != nil {
+			return nil, fmt.Errorf("failed to compile schema %v: %w", url, err)
+		}
+	}
+	v.schemas[fileName] = compiled
+	return compiled, nil
+}
+
+// collects the leaf errors of a validation error tree
+func leafValidationErrors(err *jsonschema.ValidationError) (leaves []*jsonschema.ValidationError) {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+	for _, cause := range err.Causes {
+		leaves = append(leaves, leafValidationErrors(cause)...)
+	}
+	return leaves
+}
+
+// validates a single rendered document
+func (v *SchemaValidator) validateDocument(document Document) (violations []SchemaViolation, err error) {
+	content, err := yaml.YAMLToJSON([]byte(document.Content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document from %v: %w", document.Source, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	var object interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("failed to parse document from %v: %w", document.Source, err)
+	}
+	if object == nil {
+		return nil, nil
+	}
+	fields, ok := object.(map[string]interface{})
+	apiVersion, _ := fields["apiVersion"].(string)
+	kind, _ := fields["kind"].(string)
+	if !ok || apiVersion == "" || kind == "" {
+		violation := SchemaViolation{
+			Document: "<unknown>",
+			Source:   document.Source,
+			Path:     "/",
+			Message:  "document is missing `apiVersion` or `kind`",
+		}
+		return []SchemaViolation{violation}, nil
+	}
+	name := "<unnamed>"
+	if metadata, ok := fields["metadata"].(map[string]interface{}); ok {
+		if n, ok := metadata["name"].(string); ok {
+			name = n
+		}
+	}
+	s, err := v.schema(schema.FromAPIVersionAndKind(apiVersion, kind))
+	if err != nil || s == nil {
+		return nil, err
+	}
+	err = s.Validate(object)
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, err
+	}
+	for _, leaf := range leafValidationErrors(validationErr) {
+		path := leaf.InstanceLocation
+		if path == "" {
+			path = "/"
+		}
+		violations = append(violations, SchemaViolation{
+			Document: kind + "/" + name,
+			Source:   document.Source,
+			Path:     path,
+			Message:  leaf.Message,
+		})
+	}
+	return violations, nil
+}
+
+// validates every document of a rendered manifest against the schema of its kind
+func (v *SchemaValidator) Validate(manifest string) (violations []SchemaViolation, err error) {
+	for _, document := range SplitManifest(manifest) {
+		documentViolations, err := v.validateDocument(document)
+		if err != nil {
+			return violations, err
+		}
+		violations = append(violations, documentViolations...)
+	}
+	return violations, nil
+}
@@ -0,0 +1,93 @@
+package helmspec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const invalidDeployment = `---
+# Source: example/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+spec:
+  replicas: "2"
+  template:
+    spec:
+      containers:
+      - name: foo
+        image: foo:1.2.3
+        ports:
+        - containerPort: 80
+          protocl: TCP
+`
+
+func TestTypeSchemasAcceptValidManifest(t *testing.T) {
+	validator, err := NewSchemaValidator("", "")
+	assert.NoError(t, err)
+	manifest, err := os.ReadFile("./testdata/example_spec_0_manifest.yaml")
+	assert.NoError(t, err)
+	violations, err := validator.Validate(string(manifest))
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestTypeSchemasRejectInvalidManifest(t *testing.T) {
+	validator, err := NewSchemaValidator(TypeSchemaKubeVersion, "")
+	assert.NoError(t, err)
+	violations, err := validator.Validate(invalidDeployment)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(violations))
+	paths := []string{}
+	for _, v := range violations {
+		assert.Equal(t, "Deployment/foo", v.Document)
+		assert.Equal(t, "example/templates/deployment.yaml", v.Source)
+		paths = append(paths, v.Path)
+	}
+	assert.ElementsMatch(t, []string{"/spec/replicas", "/spec/template/spec/containers/0/ports/0"}, paths)
+}
+
+func TestSchemaValidatorRequiresSchemaDirForOtherVersions(t *testing.T) {
+	_, err := NewSchemaValidator("1.10.0", "")
+	assert.ErrorContains(t, err, "no type schemas")
+	_, err = NewSchemaValidator("not a version", "")
+	assert.ErrorContains(t, err, "invalid kube version")
+}
+
+func TestSchemaValidatorUsesSchemaDir(t *testing.T) {
+	schemaDir := t.TempDir()
+	versionDir := filepath.Join(schemaDir, "v1.10.0-standalone-strict")
+	assert.NoError(t, os.MkdirAll(versionDir, 0o755))
+	schema := `{"type": "object", "required": ["spec"]}`
+	assert.NoError(t, os.WriteFile(filepath.Join(versionDir, "deployment-apps-v1.json"), []byte(schema), 0o644))
+	validator, err := NewSchemaValidator("1.10.0", schemaDir)
+	assert.NoError(t, err)
+	violations, err := validator.Validate("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: foo\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(violations))
+	// kinds without a schema are not validated
+	violations, err = validator.Validate("apiVersion: v1\nkind: Service\nmetadata:\n  name: foo\n")
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
+
+func TestExecuteTestCaseReportsSchemaViolations(t *testing.T) {
+	validator, err := NewSchemaValidator("", "")
+	assert.NoError(t, err)
+	testCase := TestCase{
+		Title: "invalid service port",
+		Render: RenderInstructions{
+			ReleaseName: "foo",
+			Values:      "service:\n  port: eighty\n",
+		},
+	}
+	result := testCase.Execute(context.Background(), "./testdata/charts/example", TestRunSettings{SchemaValidator: validator})
+	assert.NoError(t, result.Error)
+	assert.False(t, result.Succeeded)
+	assert.NotEmpty(t, result.SchemaViolations)
+}
@@ -0,0 +1,46 @@
+package helmspec
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// deep merges the values of src into dst, values of src take precedence
+func mergeValues(dst, src map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(dst))
+	for k, v := range dst {
+		out[k] = v
+	}
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]interface{}); ok {
+			if dstMap, ok := out[k].(map[string]interface{}); ok {
+				out[k] = mergeValues(dstMap, srcMap)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// deep merges two inline yaml values documents, values of src take precedence
+func mergeValuesYAML(dst string, src string) (string, error) {
+	if strings.TrimSpace(dst) == "" {
+		return src, nil
+	}
+	if strings.TrimSpace(src) == "" {
+		return dst, nil
+	}
+	dstValues := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(dst), &dstValues); err != nil {
+		return "", fmt.Errorf("failed to parse values: %w", err)
+	}
+	srcValues := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(src), &srcValues); err != nil {
+		return "", fmt.Errorf("failed to parse values: %w", err)
+	}
+	merged, err := yaml.Marshal(mergeValues(dstValues, srcValues))
+	return string(merged), err
+}
@@ -0,0 +1,283 @@
+package helmspec
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// the keys leading to a value in a values tree, i.e. ["image", "tag"]
+type valuesPath []string
+
+func (p valuesPath) String() string {
+	return strings.Join(p, ".")
+}
+
+// returns true if p is a prefix of other or equal to it
+func (p valuesPath) contains(other valuesPath) bool {
+	if len(p) > len(other) {
+		return false
+	}
+	for idx := range p {
+		if p[idx] != other[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// returns the paths of all leaves of a values tree, lists and empty maps are leaves
+func valuesLeaves(values map[string]interface{}, prefix valuesPath) (leaves []valuesPath) {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		path := append(append(valuesPath{}, prefix...), key)
+		if nested, ok := values[key].(map[string]interface{}); ok && len(nested) > 0 {
+			leaves = append(leaves, valuesLeaves(nested, path)...)
+			continue
+		}
+		leaves = append(leaves, path)
+	}
+	return leaves
+}
+
+// returns the paths of the leaves of a values tree that set a value. Empty maps and
+// lists are merged into the chart's defaults by helm and do not override anything
+func overridingLeaves(values map[string]interface{}) (leaves []valuesPath) {
+	for _, path := range valuesLeaves(values, nil) {
+		value, _ := lookupValue(values, path)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				continue
+			}
+		}
+		leaves = append(leaves, path)
+	}
+	return leaves
+}
+
+// returns the value at a path of a values tree
+func lookupValue(values map[string]interface{}, path valuesPath) (value interface{}, ok bool) {
+	value = values
+	for _, key := range path {
+		m, isMap := value.(map[string]interface{})
+		if !isMap {
+			return nil, false
+		}
+		if value, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// returns a values tree that only sets the value at path
+func valuesAt(path valuesPath, value interface{}) map[string]interface{} {
+	values := map[string]interface{}{path[len(path)-1]: value}
+	for idx := len(path) - 2; idx >= 0; idx-- {
+		values = map[string]interface{}{path[idx]: values}
+	}
+	return values
+}
+
+// reads the default values of a chart, charts without values.yaml have none
+func chartDefaultValues(chartPath string) (values map[string]interface{}, err error) {
+	values = map[string]interface{}{}
+	content, err := os.ReadFile(filepath.Join(chartPath, "values.yaml"))
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return values, err
+	}
+	err = yaml.Unmarshal(content, &values)
+	return values, err
+}
+
+// returns the values a test case overrides with values files, default values, inline
+// values and the `-f`, `--set`, `--set-string`, `--set-file` and `--set-json` extra arguments
+func (r RenderInstructions) overriddenValues() (map[string]interface{}, error) {
+	opts, err := parseValuesFlags(r.ExtraArgs)
+	if err != nil {
+		return nil, err
+	}
+	return r.mergeValues(opts)
+}
+
+// returns the values a test case overrides without extra arguments. Only these
+// can be reset by overriding the inline values, extra arguments take precedence
+func (r RenderInstructions) resettableValues() (map[string]interface{}, error) {
+	return r.mergeValues(sdkTemplateOptions{})
+}
+
+// whether a key of the chart's default values is overridden by any test case
+type ValuesKeyCoverage struct {
+	// dotted path of a leaf in the chart's values.yaml, i.e. `image.tag`
+	Path string `json:"path"`
+	// set if at least one test case overrides the key or one of its parents
+	Covered bool `json:"covered"`
+}
+
+// which default values of a chart are overridden by the test cases of the specs
+type ValuesCoverage struct {
+	ChartPath string              `json:"chartPath"`
+	Keys      []ValuesKeyCoverage `json:"keys"`
+	// keys that are overridden, but no assertion result changes without the override.
+	// Keys only overridden by extra arguments like `--set` are not checked
+	Ineffective []string `json:"ineffective"`
+	// share of covered keys in percent
+	Percentage float64 `json:"percentage"`
+}
+
+// a test case whose overrides are checked for their effect on assertions
+type overrideCheck struct {
+	testCase TestCase
+	baseline TestCaseResult
+}
+
+// returns true if two results of a test case report the same assertion results
+func sameOutcome(t TestCase, a TestCaseResult, b TestCaseResult) bool {
+	if a.Succeeded != b.Succeeded || (a.Error == nil) != (b.Error == nil) || len(a.AssertionResults) != len(b.AssertionResults) {
+		return false
+	}
+	if t.Snapshot && a.Manifest != b.Manifest {
+		return false
+	}
+	for idx := range a.AssertionResults {
+		if a.AssertionResults[idx].ActualResult != b.AssertionResults[idx].ActualResult ||
+			a.AssertionResults[idx].Succeeded != b.AssertionResults[idx].Succeeded {
+			return false
+		}
+	}
+	return true
+}
+
+// computes which default values of each chart are overridden by the specs' values files
+// and inline values. To find overrides without effect, every test case is rendered again
+// once per overridden key with that key reset to its default
+func ComputeValuesCoverage(ctx context.Context, specs []*HelmSpec, settings TestRunSettings) (coverage []ValuesCoverage, err error) {
+	settings.SchemaValidator = nil
+	charts := []string{}
+	specsByChart := map[string][]*HelmSpec{}
+	for _, spec := range specs {
+		if _, ok := specsByChart[spec.ChartPath]; !ok {
+			charts = append(charts, spec.ChartPath)
+		}
+		specsByChart[spec.ChartPath] = append(specsByChart[spec.ChartPath], spec)
+	}
+	dependencies := newDependencyCache(ctx, settings)
+	for _, chartPath := range charts {
+		defaults, err := chartDefaultValues(chartPath)
+		if err != nil {
+			return coverage, err
+		}
+		overridden := map[string]valuesPath{}
+		checks := []*overrideCheck{}
+		for _, spec := range specsByChart[chartPath] {
+			for _, testCase := range spec.TestCases {
+				values, err := testCase.Render.overriddenValues()
+				if err != nil {
+					return coverage, err
+				}
+				for _, path := range overridingLeaves(values) {
+					overridden[path.String()] = path
+				}
+				if testCase.Render.ExpectsFailure() || len(testCase.Assertions) > 0 || testCase.Snapshot {
+					checks = append(checks, &overrideCheck{testCase: testCase})
+				}
+			}
+		}
+		// without dependencies no rendering tells anything about the values
+		if err := dependencies.Build(ctx, chartPath); err != nil {
+			return coverage, err
+		}
+		// render every test case as it is
+		parallelize(len(checks), settings.Jobs, func(idx int) {
+			c := checks[idx]
+			c.baseline = c.testCase.Execute(ctx, chartPath, settings)
+		})
+		// render every test case once per overridden key with the key reset to its default
+		type mutation struct {
+			check *overrideCheck
+			path  valuesPath
+		}
+		mutations := []mutation{}
+		for _, c := range checks {
+			// overrides can only be checked if the test case renders as expected
+			if c.baseline.Error != nil && !c.testCase.Render.ExpectsFailure() {
+				continue
+			}
+			values, err := c.testCase.Render.resettableValues()
+			if err != nil {
+				return coverage, err
+			}
+			for _, path := range overridingLeaves(values) {
+				mutations = append(mutations, mutation{check: c, path: path})
+			}
+		}
+		effective := make([]bool, len(mutations))
+		errs := make([]error, len(mutations))
+		parallelize(len(mutations), settings.Jobs, func(idx int) {
+			m := mutations[idx]
+			defaultValue, _ := lookupValue(defaults, m.path)
+			reset, err := yaml.Marshal(valuesAt(m.path, defaultValue))
+			if err != nil {
+				errs[idx] = err
+				return
+			}
+			testCase := m.check.testCase
+			if testCase.Render.Values, err = mergeValuesYAML(testCase.Render.Values, string(reset)); err != nil {
+				errs[idx] = err
+				return
+			}
+			effective[idx] = !sameOutcome(testCase, m.check.baseline, testCase.Execute(ctx, chartPath, settings))
+		})
+		if err := ctx.Err(); err != nil {
+			return coverage, err
+		}
+		isEffective := map[string]bool{}
+		for idx, m := range mutations {
+			if errs[idx] != nil {
+				return coverage, errs[idx]
+			}
+			isEffective[m.path.String()] = isEffective[m.path.String()] || effective[idx]
+		}
+
+		c := ValuesCoverage{ChartPath: chartPath, Keys: []ValuesKeyCoverage{}, Ineffective: []string{}, Percentage: 100}
+		covered := 0
+		for _, leaf := range valuesLeaves(defaults, nil) {
+			key := ValuesKeyCoverage{Path: leaf.String()}
+			for _, path := range overridden {
+				if path.contains(leaf) || leaf.contains(path) {
+					key.Covered = true
+					covered++
+					break
+				}
+			}
+			c.Keys = append(c.Keys, key)
+		}
+		for name := range overridden {
+			if effective, checked := isEffective[name]; checked && !effective {
+				c.Ineffective = append(c.Ineffective, name)
+			}
+		}
+		sort.Strings(c.Ineffective)
+		if len(c.Keys) > 0 {
+			c.Percentage = 100 * float64(covered) / float64(len(c.Keys))
+		}
+		coverage = append(coverage, c)
+	}
+	return coverage, nil
+}
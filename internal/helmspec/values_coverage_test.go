@@ -0,0 +1,87 @@
+package helmspec
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValuesLeaves(t *testing.T) {
+	values := map[string]interface{}{
+		"image":          map[string]interface{}{"tag": "1.0.0", "repository": "nginx"},
+		"podAnnotations": map[string]interface{}{},
+		"tolerations":    []interface{}{"a"},
+	}
+	leaves := []string{}
+	for _, leaf := range valuesLeaves(values, nil) {
+		leaves = append(leaves, leaf.String())
+	}
+	assert.Equal(t, []string{"image.repository", "image.tag", "podAnnotations", "tolerations"}, leaves)
+}
+
+func TestOverridingLeavesSkipEmptyMapsAndLists(t *testing.T) {
+	values := map[string]interface{}{
+		"image":       map[string]interface{}{},
+		"tolerations": []interface{}{},
+		"tag":         "",
+		"affinity":    nil,
+	}
+	leaves := []string{}
+	for _, leaf := range overridingLeaves(values) {
+		leaves = append(leaves, leaf.String())
+	}
+	assert.Equal(t, []string{"affinity", "tag"}, leaves)
+}
+
+func TestOverriddenValuesIncludeValuesFlags(t *testing.T) {
+	r := RenderInstructions{
+		Values:    "image:\n  tag: 1.0.0\n",
+		ExtraArgs: []string{"--namespace", "foo", "--set", "replicaCount=2", "--set-string=service.port=80", "--debug"},
+	}
+	values, err := r.overriddenValues()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"image":        map[string]interface{}{"tag": "1.0.0"},
+		"replicaCount": int64(2),
+		"service":      map[string]interface{}{"port": "80"},
+	}, values)
+	values, err = r.resettableValues()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"image": map[string]interface{}{"tag": "1.0.0"}}, values)
+}
+
+func TestValuesPathContains(t *testing.T) {
+	assert.True(t, valuesPath{"image"}.contains(valuesPath{"image", "tag"}))
+	assert.True(t, valuesPath{"image", "tag"}.contains(valuesPath{"image", "tag"}))
+	assert.False(t, valuesPath{"image", "tag"}.contains(valuesPath{"image"}))
+	assert.False(t, valuesPath{"image"}.contains(valuesPath{"imagePullSecrets"}))
+}
+
+func TestComputeValuesCoverage(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/defaults_spec.yaml")
+	assert.NoError(t, err)
+	coverage, err := ComputeValuesCoverage(context.Background(), []*HelmSpec{spec}, TestRunSettings{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(coverage))
+	keys := map[string]bool{}
+	for _, key := range coverage[0].Keys {
+		keys[key.Path] = key.Covered
+	}
+	assert.True(t, keys["image.tag"])
+	assert.True(t, keys["replicaCount"])
+	assert.True(t, keys["image.repository"])
+	assert.False(t, keys["service.port"])
+	assert.False(t, keys["affinity"])
+	assert.False(t, keys["autoscaling.enabled"])
+	assert.InDelta(t, 14.3, coverage[0].Percentage, 0.1)
+	// the pull policy is set by the spec defaults, but no assertion checks it
+	assert.Equal(t, []string{"image.pullPolicy"}, coverage[0].Ineffective)
+}
+
+func TestComputeValuesCoverageFailsWhenDependenciesFailToBuild(t *testing.T) {
+	spec, err := NewSpec("./testdata/charts/example/specs/defaults_spec.yaml")
+	assert.NoError(t, err)
+	_, err = ComputeValuesCoverage(context.Background(), []*HelmSpec{spec}, TestRunSettings{HelmBinary: "./not/an/existing/helm"})
+	assert.ErrorContains(t, err, "failed to build dependencies")
+}
@@ -0,0 +1,61 @@
+package testreport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/pterm/pterm"
+	"sigs.k8s.io/yaml"
+)
+
+const covered = "\u2705 covered"
+const uncovered = "\u274c uncovered"
+const ineffective = "\u26a0\ufe0f overridden without affecting any assertion"
+
+// reports which chart values are overridden by specs as pretty text or yaml
+func ValuesCoverageReport(coverage []helmspec.ValuesCoverage, settings TestReportSettings) (string, error) {
+	switch settings.OutputFormat {
+	case OutputFormatYAML:
+		content, err := yaml.Marshal(coverage)
+		return string(content), err
+	case OutputFormatPretty:
+		return prettyValuesCoverageReport(coverage, settings), nil
+	default:
+		return "", fmt.Errorf("unsupported output format `%v` for values coverage", settings.OutputFormat)
+	}
+}
+
+func prettyValuesCoverageReport(coverage []helmspec.ValuesCoverage, settings TestReportSettings) string {
+	coveredText, uncoveredText, ineffectiveText := covered, uncovered, ineffective
+	if settings.UseColor {
+		coveredText = pterm.FgGreen.Sprint(covered)
+		uncoveredText = pterm.FgRed.Sprint(uncovered)
+		ineffectiveText = pterm.FgYellow.Sprint(ineffective)
+	}
+	report := ""
+	for _, c := range coverage {
+		count := 0
+		for _, key := range c.Keys {
+			if key.Covered {
+				count++
+			}
+		}
+		report += fmt.Sprintf("%v: %v of %v values covered (%.1f%%)\n", c.ChartPath, count, len(c.Keys), c.Percentage)
+		for _, key := range c.Keys {
+			status := uncoveredText
+			if key.Covered {
+				status = coveredText
+			}
+			report += fmt.Sprintf("%v%v - %v\n", strings.Repeat(" ", 4), status, key.Path)
+		}
+		if len(c.Ineffective) > 0 {
+			report += strings.Repeat(" ", 4) + ineffectiveText + ":\n"
+			for _, path := range c.Ineffective {
+				report += strings.Repeat(" ", 8) + path + "\n"
+			}
+		}
+		report += "\n"
+	}
+	return report
+}
@@ -0,0 +1,45 @@
+package testreport
+
+import (
+	"testing"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+var testValuesCoverage = []helmspec.ValuesCoverage{{
+	ChartPath: "/charts/example",
+	Keys: []helmspec.ValuesKeyCoverage{
+		{Path: "image.pullPolicy", Covered: true},
+		{Path: "image.tag", Covered: true},
+		{Path: "replicaCount", Covered: false},
+	},
+	Ineffective: []string{"image.pullPolicy"},
+	Percentage:  200.0 / 3,
+}}
+
+func TestPrettyValuesCoverageReport(t *testing.T) {
+	output, err := ValuesCoverageReport(testValuesCoverage, TestReportSettings{OutputFormat: OutputFormatPretty})
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, output, "/charts/example: 2 of 3 values covered (66.7%)")
+	assert.Contains(t, output, covered+" - image.tag")
+	assert.Contains(t, output, uncovered+" - replicaCount")
+	assert.Contains(t, output, ineffective+":\n        image.pullPolicy")
+}
+
+func TestYamlValuesCoverageReport(t *testing.T) {
+	output, err := ValuesCoverageReport(testValuesCoverage, TestReportSettings{OutputFormat: OutputFormatYAML})
+	assert.NoError(t, err)
+	reported := []helmspec.ValuesCoverage{}
+	assert.NoError(t, yaml.Unmarshal([]byte(output), &reported))
+	assert.Equal(t, testValuesCoverage, reported)
+}
+
+func TestValuesCoverageReportRejectsJUnit(t *testing.T) {
+	_, err := ValuesCoverageReport(testValuesCoverage, TestReportSettings{OutputFormat: OutputFormatJUnit})
+	assert.ErrorContains(t, err, "unsupported output format")
+}
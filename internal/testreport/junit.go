@@ -0,0 +1,187 @@
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// the content of a <failure> or <error> element
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// describes a failed assertion with query, expected and actual value
+func junitAssertionFailure(result helmspec.AssertionResult) string {
+	body := result.Assertion.Description + "\n"
+	if result.Assertion.Document != nil {
+		body += fmt.Sprintf("document:\n%v\n", result.Assertion.Document)
+	}
+	body += fmt.Sprintf("query:\n%v\nwant (%v):\n%v\ngot:\n%v\n",
+		result.Assertion.Query,
+		result.Assertion.ResolvedOperator(),
+		result.Assertion.ExpectedResult,
+		result.ActualResult,
+	)
+	if result.Diff != "" {
+		body += fmt.Sprintf("diff:\n%v", result.Diff)
+	}
+	if result.Error != nil {
+		body += fmt.Sprintf("error:\n%v\n", result.Error)
+	}
+	return body
+}
+
+// describes a snapshot mismatch with the diff against the stored snapshot
+func junitSnapshotFailure(result helmspec.SnapshotResult) string {
+	body := fmt.Sprintf("snapshot %v\n", result.Path)
+	if result.Error != nil {
+		return body + fmt.Sprintf("error:\n%v\n", result.Error)
+	}
+	return body + fmt.Sprintf("diff:\n%v", result.Diff)
+}
+
+// appends the command line reproducing the rendering to a failure description
+func withCommand(body string, result helmspec.TestCaseResult) string {
+	if result.Command == "" {
+		return body
+	}
+	return body + fmt.Sprintf("command:\n%v\n", result.Command)
+}
+
+func junitTestCaseReport(result helmspec.TestCaseResult, classname string) junitTestCase {
+	testCase := junitTestCase{
+		Name:      result.Title,
+		Classname: classname,
+	}
+	if result.Skipped {
+		testCase.Skipped = &junitSkipped{Message: "filtered out"}
+		return testCase
+	}
+	if result.Succeeded {
+		return testCase
+	}
+	if result.Render.ExpectsFailure() && result.Error != nil {
+		testCase.Failure = &junitProblem{
+			Message: "rendering failed with an unexpected error",
+			Type:    "RenderErrorMismatch",
+			Body:    withCommand(fmt.Sprintf("want error:\n%v\ngot error:\n%v\n", result.Render.ExpectedError, helmspec.RenderErrorMessage(result.Error)), result),
+		}
+		return testCase
+	}
+	if result.Render.ExpectsFailure() {
+		testCase.Failure = &junitProblem{
+			Message: "rendering succeeded but was expected to fail",
+			Type:    "RenderSucceeded",
+			Body:    result.Manifest,
+		}
+		return testCase
+	}
+	if result.Error != nil {
+		testCase.Error = &junitProblem{
+			Message: "failed to render chart",
+			Type:    "RenderError",
+			Body:    withCommand(result.Error.Error()+"\n", result),
+		}
+		return testCase
+	}
+	failures := []string{}
+	for _, a := range result.AssertionResults {
+		if !a.Succeeded {
+			failures = append(failures, junitAssertionFailure(a))
+		}
+	}
+	message := fmt.Sprintf("%v of %v assertions failed", len(failures), len(result.AssertionResults))
+	if len(result.SchemaViolations) > 0 {
+		violations := []string{}
+		for _, v := range result.SchemaViolations {
+			violations = append(violations, v.String())
+		}
+		failures = append(failures, fmt.Sprintf("schema validation\n%v\n", strings.Join(violations, "\n")))
+		message += fmt.Sprintf(", %v schema violations", len(result.SchemaViolations))
+	}
+	if result.Snapshot != nil && !result.Snapshot.Succeeded {
+		failures = append(failures, junitSnapshotFailure(*result.Snapshot))
+		message += ", snapshot does not match"
+	}
+	testCase.Failure = &junitProblem{
+		Message: message,
+		Type:    "AssertionFailed",
+		Body:    withCommand(strings.Join(failures, "\n"), result),
+	}
+	return testCase
+}
+
+func junitSpecReport(result helmspec.SpecResult) junitTestSuite {
+	suite := junitTestSuite{
+		Name:  result.Title,
+		Tests: len(result.TestCaseResults),
+	}
+	for _, c := range result.TestCaseResults {
+		testCase := junitTestCaseReport(c, result.Title)
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		if testCase.Error != nil {
+			suite.Errors++
+		}
+		if testCase.Skipped != nil {
+			suite.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+	return suite
+}
+
+// maps each spec to a <testsuite> and each test case to a <testcase>
+func junitReport(result helmspec.TestSuiteResult) (string, error) {
+	suites := junitTestSuites{Name: "helm-spec"}
+	for _, s := range result.SpecResults {
+		suite := junitSpecReport(s)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+	content, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(content) + "\n", nil
+}
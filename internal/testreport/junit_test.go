@@ -0,0 +1,74 @@
+package testreport
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJUnitReport(t *testing.T) {
+	result := helmspec.TestSuiteResult{
+		Succeeded: false,
+		SpecResults: []helmspec.SpecResult{{
+			Title:     "example",
+			Succeeded: false,
+			TestCaseResults: []helmspec.TestCaseResult{
+				{
+					Title:     "successful",
+					Succeeded: true,
+				},
+				{
+					Title:     "failed assertion",
+					Succeeded: false,
+					AssertionResults: []helmspec.AssertionResult{{
+						Succeeded:    false,
+						ActualResult: "foo",
+						Assertion: helmspec.Assertion{
+							Description:    "deployment name should be `bar`",
+							ExpectedResult: "bar",
+							Query:          "select(.kind==\"Deployment\") | .metadata.name",
+						},
+					}},
+				},
+				{
+					Title:     "filtered out",
+					Succeeded: true,
+					Skipped:   true,
+				},
+				{
+					Title:     "render error",
+					Succeeded: false,
+					Error:     errors.New("exit status 1"),
+				},
+			},
+		}},
+	}
+	output, err := HelmTestReporter{}.Report(result, TestReportSettings{OutputFormat: OutputFormatJUnit})
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	report := junitTestSuites{}
+	assert.NoError(t, xml.Unmarshal([]byte(output), &report))
+	assert.Equal(t, 4, report.Tests)
+	assert.Equal(t, 1, report.Failures)
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, len(report.Suites))
+	suite := report.Suites[0]
+	assert.Equal(t, "example", suite.Name)
+	assert.Equal(t, 4, len(suite.TestCases))
+	assert.Nil(t, suite.TestCases[0].Failure)
+	assert.Nil(t, suite.TestCases[0].Error)
+	failure := suite.TestCases[1].Failure
+	assert.NotNil(t, failure)
+	assert.Contains(t, failure.Body, "select(.kind==\"Deployment\") | .metadata.name")
+	assert.Contains(t, failure.Body, "want (equals):\nbar")
+	assert.Contains(t, failure.Body, "got:\nfoo")
+	assert.NotNil(t, suite.TestCases[2].Skipped)
+	assert.NotNil(t, suite.TestCases[3].Error)
+	assert.Contains(t, suite.TestCases[3].Error.Body, "exit status 1")
+}
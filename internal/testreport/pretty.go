@@ -11,6 +11,7 @@ import (
 
 const pass = "\u2705 passed"
 const fail = "\u274c failed"
+const skipped = "\u23ed\ufe0f skipped"
 
 // returns `passed` or `failed`
 func passOrFailNoColor(succeeded bool) string {
@@ -32,12 +33,24 @@ func passOrFail(succeeded bool) string {
 
 const assertionTmpl = `        {{ passOrFail .Succeeded }} - {{ .Assertion.Description }}
 		{{- if (not .Succeeded) }}
+		{{- if .Assertion.Document }}
+		document:
+		    {{ .Assertion.Document }}
+		{{- end }}
 		query: 
 		    {{ .Assertion.Query }}
-		want:
-			{{ .Assertion.ExpectedResult }}
+		{{- if .Error }}
+		error:
+			{{ .Error }}
+		{{- else if .Diff }}
+		diff ({{ .Assertion.ResolvedOperator }}):
+{{ indent (colorDiff .Diff) }}
+		{{- else }}
+		want ({{ .Assertion.ResolvedOperator }}):
+			{{ if isUnary .Assertion.ResolvedOperator }}-{{ else }}{{ .Assertion.ExpectedResult }}{{ end }}
 		got:
 			{{ .ActualResult }}
+		{{- end }}
 		{{- end }}`
 
 func prettyAssertionReport(result helmspec.AssertionResult, settings TestReportSettings) (string, error) {
@@ -48,6 +61,12 @@ func prettyAssertionReport(result helmspec.AssertionResult, settings TestReportS
 	} else {
 		funcMap["passOrFail"] = passOrFailNoColor
 	}
+	funcMap["isUnary"] = helmspec.IsUnaryOperator
+	funcMap["indent"] = indent
+	funcMap["colorDiff"] = colorDiffNoColor
+	if settings.UseColor {
+		funcMap["colorDiff"] = colorDiff
+	}
 	tpl, err := template.New("assertion").Funcs(funcMap).Parse(assertionTmpl)
 	if err != nil {
 		return "", err
@@ -57,7 +76,109 @@ func prettyAssertionReport(result helmspec.AssertionResult, settings TestReportS
 	return buf.String(), err
 }
 
-const testCaseTmpl = "    {{ passOrFail .Succeeded }} - {{ .Title }}"
+const snapshotTmpl = `        {{ passOrFail .Succeeded }} - snapshot {{ .Path }}
+		{{- if .Updated }} (updated){{ end }}
+		{{- if .Error }}
+		error:
+			{{ .Error }}
+		{{- else if (not .Succeeded) }}
+		diff:
+{{ indent (colorDiff .Diff) }}
+		{{- end }}`
+
+func colorDiffNoColor(diff string) string {
+	return diff
+}
+
+// colors removed lines of a unified diff red, added lines green and hunk headers cyan
+func colorDiff(diff string) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for idx, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			lines[idx] = pterm.Bold.Sprint(line)
+		case strings.HasPrefix(line, "-"):
+			lines[idx] = pterm.FgRed.Sprint(line)
+		case strings.HasPrefix(line, "+"):
+			lines[idx] = pterm.FgGreen.Sprint(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[idx] = pterm.FgCyan.Sprint(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indents every line of a multi-line text like the values in the report templates
+func indent(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for idx, line := range lines {
+		lines[idx] = "\t\t\t" + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+func prettySnapshotReport(result helmspec.SnapshotResult, settings TestReportSettings) (string, error) {
+	buf := &strings.Builder{}
+	funcMap := make(map[string]any)
+	if settings.UseColor {
+		funcMap["passOrFail"] = passOrFail
+	} else {
+		funcMap["passOrFail"] = passOrFailNoColor
+	}
+	funcMap["indent"] = indent
+	funcMap["colorDiff"] = colorDiffNoColor
+	if settings.UseColor {
+		funcMap["colorDiff"] = colorDiff
+	}
+	tpl, err := template.New("snapshot").Funcs(funcMap).Parse(snapshotTmpl)
+	if err != nil {
+		return "", err
+	}
+	err = tpl.Execute(buf, result)
+	return buf.String(), err
+}
+
+const testCaseTmpl = `    {{ if .Skipped }}{{ skipped }}{{ else }}{{ passOrFail .Succeeded }}{{ end }} - {{ .Title }}
+		{{- if and .Parameters (not .Succeeded) }}
+		parameters:
+			{{ formatParameters .Parameters }}
+		{{- end }}
+		{{- if and .Render.ExpectsFailure (not .Succeeded) }}
+		want error:
+{{ indent (or .Render.ExpectedError "any") }}
+		got error:
+{{ if .Error }}{{ indent (renderErrorMessage .Error) }}{{ else }}{{ indent "none, rendering succeeded" }}{{ end }}
+		{{- else if not .Succeeded }}
+		{{- if .Error }}
+		error:
+{{ indent (errorMessage .Error) }}
+		{{- end }}
+		{{- if .Stderr }}
+		stderr:
+{{ indent .Stderr }}
+		{{- end }}
+		{{- end }}
+		{{- if and .Command (not .Succeeded) }}
+		command:
+{{ indent .Command }}
+		{{- end }}`
+
+// returns the message of an unexpected error, leaving out what helm wrote to
+// stderr since the report prints it separately
+func errorMessage(err error) string {
+	if renderErr, ok := err.(*helmspec.RenderError); ok {
+		return renderErr.Err.Error()
+	}
+	return err.Error()
+}
+
+func skippedNoColor() string {
+	return skipped
+}
+
+func skippedColor() string {
+	return pterm.FgGray.Sprint(skipped)
+}
 
 func prettyTestCaseReport(result helmspec.TestCaseResult, settings TestReportSettings) (string, error) {
 	buf := &strings.Builder{}
@@ -67,6 +188,15 @@ func prettyTestCaseReport(result helmspec.TestCaseResult, settings TestReportSet
 	} else {
 		funcMap["passOrFail"] = passOrFailNoColor
 	}
+	if settings.UseColor {
+		funcMap["skipped"] = skippedColor
+	} else {
+		funcMap["skipped"] = skippedNoColor
+	}
+	funcMap["indent"] = indent
+	funcMap["renderErrorMessage"] = helmspec.RenderErrorMessage
+	funcMap["errorMessage"] = errorMessage
+	funcMap["formatParameters"] = helmspec.FormatParameters
 	tpl, err := template.New("testCase").Funcs(funcMap).Parse(testCaseTmpl)
 	if err != nil {
 		return "", err
@@ -81,6 +211,24 @@ func prettyTestCaseReport(result helmspec.TestCaseResult, settings TestReportSet
 		report += "\n"
 		report += assertionReport
 	}
+	if len(result.SchemaViolations) > 0 {
+		status := passOrFailNoColor(false)
+		if settings.UseColor {
+			status = passOrFail(false)
+		}
+		report += "\n" + strings.Repeat(" ", 8) + status + " - schema validation"
+		for _, v := range result.SchemaViolations {
+			report += "\n" + strings.Repeat(" ", 12) + v.String()
+		}
+	}
+	if result.Snapshot != nil {
+		snapshotReport, err := prettySnapshotReport(*result.Snapshot, settings)
+		if err != nil {
+			return report, err
+		}
+		report += "\n"
+		report += snapshotReport
+	}
 	if !result.Succeeded && settings.Verbose {
 		manifestLines := strings.Split(result.Manifest, "\n")
 		for idx, line := range manifestLines {
@@ -88,14 +236,23 @@ func prettyTestCaseReport(result helmspec.TestCaseResult, settings TestReportSet
 		}
 		report += "\n" + strings.Repeat(" ", 8) + "\U0001f4a1 manifest:\n"
 		report += strings.Join(manifestLines, "\n")
-		if result.Error != nil {
-			report += "\n" + strings.Repeat(" ", 8) + "\u26a0\ufe0f error:\n"
-			report += strings.Repeat(" ", 12) + result.Error.Error() + "\n"
-		}
 	}
 	return report, err
 }
 
+// lists the templates that were never rendered and the coverage per chart
+func prettyCoverageReport(coverage []helmspec.TemplateCoverage) string {
+	report := "\n" + strings.Repeat("=", 32) + " coverage " + strings.Repeat("=", 31) + "\n\n"
+	for _, c := range coverage {
+		report += fmt.Sprintf("%v: %v of %v templates rendered (%.1f%%)\n", c.ChartPath, len(c.Rendered), c.Templates, c.Percentage)
+		for _, template := range c.Unrendered {
+			report += strings.Repeat(" ", 4) + "never rendered: " + template + "\n"
+		}
+	}
+	report += fmt.Sprintf("total: %.1f%%\n", helmspec.TotalTemplateCoverage(coverage))
+	return report
+}
+
 func prettySpecReport(result helmspec.SpecResult, settings TestReportSettings) (string, error) {
 	var status string
 	if settings.UseColor {
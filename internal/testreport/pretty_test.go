@@ -1,10 +1,14 @@
 package testreport
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/bujarmurati/helm-spec/internal/helmspec"
+	"github.com/pterm/pterm"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -136,3 +140,237 @@ func TestPrettyFailedTestCaseReport(t *testing.T) {
 	failedAssertions := strings.TrimSpace(lines[2])
 	assert.Contains(t, failedAssertions, fail)
 }
+
+func TestPrettyFailedSnapshotReport(t *testing.T) {
+	settings := TestReportSettings{
+		UseColor:     false,
+		OutputFormat: "pretty",
+	}
+	res := helmspec.SnapshotResult{
+		Path:      "specs/__snapshots__/example_spec/foo.yaml",
+		Succeeded: false,
+		Diff:      "--- snapshot\n+++ rendered\n@@ -1 +1 @@\n-foo: bar\n+foo: baz\n",
+	}
+	output, err := prettySnapshotReport(res, settings)
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 7, len(lines))
+	assert.Contains(t, lines[0], fail)
+	assert.Contains(t, lines[0], res.Path)
+	assert.Equal(t, "-foo: bar", strings.TrimSpace(lines[5]))
+}
+
+func TestPrettySchemaViolationsReport(t *testing.T) {
+	settings := TestReportSettings{
+		UseColor:     false,
+		OutputFormat: "pretty",
+	}
+	res := helmspec.TestCaseResult{
+		Title:     "deployment",
+		Succeeded: false,
+		SchemaViolations: []helmspec.SchemaViolation{{
+			Document: "Deployment/foo",
+			Source:   "example/templates/deployment.yaml",
+			Path:     "/spec/replicas",
+			Message:  "expected integer, but got string",
+		}},
+	}
+	output, err := prettyTestCaseReport(res, settings)
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[1], "schema validation")
+	assert.Contains(t, lines[2], "Deployment/foo at /spec/replicas")
+}
+
+func TestPrettySkippedTestCaseReport(t *testing.T) {
+	settings := TestReportSettings{
+		UseColor:     false,
+		OutputFormat: "pretty",
+	}
+	res := helmspec.TestCaseResult{
+		Title:     "deployment",
+		Succeeded: true,
+		Skipped:   true,
+	}
+	output, err := prettyTestCaseReport(res, settings)
+	assert.NoError(t, err)
+	assert.Equal(t, "    "+skipped+" - deployment", output)
+}
+
+func TestPrettyUnexpectedRenderErrorReport(t *testing.T) {
+	settings := TestReportSettings{
+		UseColor:     false,
+		OutputFormat: "pretty",
+	}
+	res := helmspec.TestCaseResult{
+		Title:     "rejects an empty tag",
+		Succeeded: false,
+		Render:    helmspec.RenderInstructions{ExpectedError: "image.tag is required"},
+		Error: &helmspec.RenderError{
+			Err:    errors.New("exit status 1"),
+			Stderr: "Error: chart not found\n",
+		},
+	}
+	output, err := prettyTestCaseReport(res, settings)
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 5, len(lines))
+	assert.Contains(t, lines[1], "want error:")
+	assert.Equal(t, "image.tag is required", strings.TrimSpace(lines[2]))
+	assert.Contains(t, lines[3], "got error:")
+	assert.Equal(t, "Error: chart not found", strings.TrimSpace(lines[4]))
+}
+
+func TestPrettyRenderErrorReport(t *testing.T) {
+	settings := TestReportSettings{
+		UseColor:     false,
+		OutputFormat: "pretty",
+	}
+	res := helmspec.TestCaseResult{
+		Title:     "deployment",
+		Succeeded: false,
+		Stderr:    "Error: chart not found\n",
+		Command:   "helm template ./chart -f - < /dev/null",
+		Error:     errors.New("exit status 1"),
+	}
+	output, err := prettyTestCaseReport(res, settings)
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 7, len(lines))
+	assert.Contains(t, lines[1], "error:")
+	assert.Equal(t, "exit status 1", strings.TrimSpace(lines[2]))
+	assert.Contains(t, lines[3], "stderr:")
+	assert.Equal(t, "Error: chart not found", strings.TrimSpace(lines[4]))
+	assert.Contains(t, lines[5], "command:")
+	assert.Equal(t, res.Command, strings.TrimSpace(lines[6]))
+}
+
+func TestPrettyTimeoutReport(t *testing.T) {
+	settings := TestReportSettings{
+		UseColor:     false,
+		OutputFormat: "pretty",
+	}
+	res := helmspec.TestCaseResult{
+		Title:     "deployment",
+		Succeeded: false,
+		Error:     fmt.Errorf("test case timed out after 1s: %w", context.DeadlineExceeded),
+	}
+	output, err := prettyTestCaseReport(res, settings)
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 3, len(lines))
+	assert.Contains(t, lines[1], "error:")
+	assert.Equal(t, "test case timed out after 1s: context deadline exceeded", strings.TrimSpace(lines[2]))
+}
+
+func TestPrettyMatrixParametersReport(t *testing.T) {
+	settings := TestReportSettings{
+		UseColor:     false,
+		OutputFormat: "pretty",
+	}
+	res := helmspec.TestCaseResult{
+		Title:      "service of type ${type}",
+		Succeeded:  false,
+		Parameters: map[string]string{"type": "NodePort", "replicas": "2"},
+		Error:      errors.New("exit status 1"),
+	}
+	output, err := prettyTestCaseReport(res, settings)
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 5, len(lines))
+	assert.Contains(t, lines[1], "parameters:")
+	assert.Equal(t, "[replicas=2, type=NodePort]", strings.TrimSpace(lines[2]))
+
+	res.Succeeded = true
+	res.Error = nil
+	output, err = prettyTestCaseReport(res, settings)
+	assert.NoError(t, err)
+	assert.NotContains(t, output, "parameters:")
+}
+
+func TestPrettyAssertionDiffReport(t *testing.T) {
+	res := helmspec.AssertionResult{
+		Succeeded:    false,
+		ActualResult: "name: app\nimage: app:1.0.0",
+		Diff:         "--- expected\n+++ actual\n@@ -1,2 +1,2 @@\n name: app\n-image: app:2.0.0\n+image: app:1.0.0\n",
+		Assertion: helmspec.Assertion{
+			Description:    "container",
+			ExpectedResult: "name: app\nimage: app:2.0.0",
+			Operator:       helmspec.OperatorEquals,
+			Query:          ".spec.containers[0]",
+		},
+	}
+	output, err := prettyAssertionReport(res, TestReportSettings{UseColor: false, OutputFormat: "pretty"})
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 10, len(lines))
+	assert.Contains(t, lines[3], "diff (equals):")
+	assert.Equal(t, "\t\t\t-image: app:2.0.0", lines[8])
+	assert.NotContains(t, output, "want")
+
+	colored, err := prettyAssertionReport(res, TestReportSettings{UseColor: true, OutputFormat: "pretty"})
+	assert.NoError(t, err)
+	assert.Contains(t, colored, pterm.FgRed.Sprint("-image: app:2.0.0"))
+	assert.Contains(t, colored, pterm.FgGreen.Sprint("+image: app:1.0.0"))
+}
+
+func TestPrettyAssertionDocumentSelectorReport(t *testing.T) {
+	selector := &helmspec.DocumentSelector{Kind: "Service", Name: "foo"}
+	res := helmspec.AssertionResult{
+		Succeeded: false,
+		Error:     fmt.Errorf("no document matches %v", selector),
+		Assertion: helmspec.Assertion{
+			Description: "service type",
+			Document:    selector,
+			Query:       ".spec.type",
+		},
+	}
+	output, err := prettyAssertionReport(res, TestReportSettings{UseColor: false, OutputFormat: "pretty"})
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.NoError(t, err)
+	lines := strings.Split(output, "\n")
+	assert.Equal(t, 7, len(lines))
+	assert.Equal(t, "{kind=Service, name=foo}", strings.TrimSpace(lines[2]))
+	assert.Equal(t, "no document matches {kind=Service, name=foo}", strings.TrimSpace(lines[6]))
+	assert.NotContains(t, output, "want")
+}
+
+func TestPrettyCoverageReport(t *testing.T) {
+	output := prettyCoverageReport([]helmspec.TemplateCoverage{{
+		ChartPath:  "/charts/example",
+		Templates:  4,
+		Rendered:   []string{"templates/deployment.yaml"},
+		Unrendered: []string{"templates/hpa.yaml", "templates/ingress.yaml", "templates/service.yaml"},
+		Percentage: 25,
+	}})
+	t.Cleanup(func() {
+		t.Logf("\n%v", output)
+	})
+	assert.Contains(t, output, "/charts/example: 1 of 4 templates rendered (25.0%)")
+	assert.Contains(t, output, "never rendered: templates/hpa.yaml")
+	assert.Contains(t, output, "total: 25.0%")
+}
@@ -10,8 +10,9 @@ import (
 
 const OutputFormatYAML = "yaml"
 const OutputFormatPretty = "pretty"
+const OutputFormatJUnit = "junit"
 
-var AllowedOutputFormats = [...]string{OutputFormatYAML, OutputFormatPretty}
+var AllowedOutputFormats = [...]string{OutputFormatYAML, OutputFormatPretty, OutputFormatJUnit}
 
 type TestReportSettings struct {
 	OutputFormat string
@@ -30,6 +31,8 @@ func (r HelmTestReporter) Report(result helmspec.TestSuiteResult, settings TestR
 	case OutputFormatYAML:
 		content, err := yaml.Marshal(result)
 		return string(content), err
+	case OutputFormatJUnit:
+		return junitReport(result)
 	case OutputFormatPretty:
 		var status string
 		if settings.UseColor {
@@ -47,6 +50,9 @@ func (r HelmTestReporter) Report(result helmspec.TestSuiteResult, settings TestR
 			}
 			report += r
 		}
+		if result.Coverage != nil {
+			report += prettyCoverageReport(result.Coverage)
+		}
 		if !settings.Verbose {
 			report += "\n\n\U0001f50d use the `--verbose` flag to view rendered manifests for failed test cases\n\n"
 		}
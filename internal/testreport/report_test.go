@@ -1,6 +1,7 @@
 package testreport
 
 import (
+	"context"
 	"testing"
 
 	"github.com/bujarmurati/helm-spec/internal/helmspec"
@@ -11,7 +12,7 @@ import (
 func TestTestReporterOutputModeYaml(t *testing.T) {
 	spec, err := helmspec.NewSpec("../helmspec/testdata/charts/example/specs/successful_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.Execute()
+	result := spec.Execute(context.Background(), helmspec.TestRunSettings{})
 	testSuiteResult := helmspec.TestSuiteResult{
 		Succeeded:   true,
 		SpecResults: []helmspec.SpecResult{result},
@@ -29,7 +30,7 @@ func TestTestReporterOutputModeYaml(t *testing.T) {
 func TestReporterOutputModePretty(t *testing.T) {
 	spec, err := helmspec.NewSpec("../helmspec/testdata/charts/example/specs/successful_spec.yaml")
 	assert.NoError(t, err)
-	result := spec.Execute()
+	result := spec.Execute(context.Background(), helmspec.TestRunSettings{})
 	testSuiteResult := helmspec.TestSuiteResult{
 		Succeeded:   true,
 		SpecResults: []helmspec.SpecResult{result},
@@ -39,3 +40,21 @@ func TestReporterOutputModePretty(t *testing.T) {
 	_, err = reporter.Report(testSuiteResult, settings)
 	assert.NoError(t, err)
 }
+
+func TestYamlReportContainsAssertionDiff(t *testing.T) {
+	assertion := helmspec.Assertion{Query: ".data", ExpectedResult: "a: 1\nb: 2"}
+	result := helmspec.TestSuiteResult{
+		SpecResults: []helmspec.SpecResult{{
+			TestCaseResults: []helmspec.TestCaseResult{{
+				AssertionResults: []helmspec.AssertionResult{assertion.Evaluate("data:\n  a: 1\n  b: 3\n")},
+			}},
+		}},
+	}
+	output, err := HelmTestReporter{}.Report(result, TestReportSettings{OutputFormat: "yaml"})
+	assert.NoError(t, err)
+	reportedResult := &helmspec.TestSuiteResult{}
+	assert.NoError(t, yaml.Unmarshal([]byte(output), reportedResult))
+	diff := reportedResult.SpecResults[0].TestCaseResults[0].AssertionResults[0].Diff
+	assert.Contains(t, diff, "-b: 2")
+	assert.Contains(t, diff, "+b: 3")
+}